@@ -0,0 +1,134 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newEncodingServer запоминает Accept-Encoding каждого запроса и сжимает ответ gzip, если клиент его принимает
+func newEncodingServer(t *testing.T) (srv *httptest.Server, accepted func(path string) string) {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]string)
+	)
+
+	var page bytes.Buffer
+	gz := gzip.NewWriter(&page)
+	_, err := gz.Write([]byte(`<html><head><meta name="robots" content="noindex"></head></html>`))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ae := r.Header.Get("Accept-Encoding")
+
+		mu.Lock()
+		seen[r.URL.Path] = ae
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/html")
+
+		if !strings.Contains(ae, "gzip") {
+			_, _ = w.Write([]byte(`<html><head><meta name="robots" content="noindex"></head></html>`))
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(page.Bytes())
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv, func(path string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return seen[path]
+	}
+}
+
+func TestAcceptEncodingAdvertised(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, accepted := newEncodingServer(t)
+
+	cases := []struct {
+		path     string
+		encoding []string
+		header   string
+		response string
+	}{
+		{path: "/identity", encoding: []string{"identity"}, header: "identity"},
+		{path: "/br-gzip", encoding: []string{"br", "gzip"}, header: "br, gzip", response: "gzip"},
+		{path: "/zstd", encoding: []string{"zstd"}, header: "zstd"},
+	}
+
+	for _, tc := range cases {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:           []string{srv.URL + tc.path},
+			Workers:        1,
+			TimeoutMS:      2000,
+			AcceptEncoding: tc.encoding,
+			RobotsMeta:     true,
+		})
+
+		require.Len(t, got, 1)
+		require.Equal(t, http.StatusOK, got[0].StatusCode, tc.path)
+		require.Equal(t, tc.header, accepted(tc.path), tc.path)
+		require.Equal(t, tc.response, got[0].ContentEncoding, tc.path)
+
+		// тело распаковано сервером, поэтому meta robots виден
+		require.Equal(t, []string{"noindex"}, got[0].Robots, tc.path)
+	}
+}
+
+func TestRawEncoding(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, accepted := newEncodingServer(t)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:           []string{srv.URL + "/raw"},
+		Workers:        1,
+		TimeoutMS:      2000,
+		AcceptEncoding: []string{"gzip"},
+		RawEncoding:    true,
+		RobotsMeta:     true,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Equal(t, "gzip", accepted("/raw"))
+	require.Equal(t, "gzip", got[0].ContentEncoding)
+	require.Empty(t, got[0].Robots)
+}
+
+func TestAcceptEncodingValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:           []string{"http://127.0.0.1/"},
+		Workers:        1,
+		TimeoutMS:      1000,
+		AcceptEncoding: []string{"gzip", "compress"},
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,409 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+func postWithKey(t *testing.T, c *http.Client, target *url.URL, key string, body any) *http.Response {
+	t.Helper()
+
+	reqBody, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, target.String(), bytes.NewReader(reqBody))
+	require.NoError(t, err)
+
+	req.Header.Set("Content-Type", contentTypeJson)
+	if key != "" {
+		req.Header.Set(apiKeyHeader, key)
+	}
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	return resp
+}
+
+// newGateServer держит каждый запрос до закрытия release и считает запросы в полёте по первому сегменту пути
+func newGateServer(t *testing.T) (srv *httptest.Server, inFlight func(prefix string) int, release func()) {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		current = make(map[string]int)
+		peak    = make(map[string]int)
+		gate    = make(chan struct{})
+		once    sync.Once
+	)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+
+		mu.Lock()
+		current[prefix]++
+		peak[prefix] = max(peak[prefix], current[prefix])
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			current[prefix]--
+			mu.Unlock()
+		}()
+
+		select {
+		case <-gate:
+		case <-r.Context().Done():
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	release = func() {
+		once.Do(func() {
+			close(gate)
+		})
+	}
+
+	// префиксы, по которым спрашивали inFlight, - это обходы одного ключа, и пик по ним проверяется в конце теста
+	queried := make(map[string]bool)
+
+	t.Cleanup(func() {
+		release()
+		srv.Close()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for prefix := range queried {
+			require.LessOrEqual(t, peak[prefix], maxConcurrentJobsPerKey, prefix)
+		}
+	})
+
+	// inFlight вызывается из условий Eventually, поэтому ничего не проверяет сам
+	inFlight = func(prefix string) int {
+		mu.Lock()
+		defer mu.Unlock()
+
+		queried[prefix] = true
+		return current[prefix]
+	}
+
+	return srv, inFlight, release
+}
+
+func TestJobQueuePerKeyLimits(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, inFlight, release := newGateServer(t)
+
+	jobs := constructJobsPath(t, baseUrl)
+
+	var ids []string
+	submit := func(key string, i int) *http.Response {
+		return postWithKey(t, c, jobs, key, CrawlRequest{
+			URLs:      makeURLs(t, srv.URL+"/"+key+"/job-"+string(rune('a'+i)), 1),
+			Workers:   1,
+			TimeoutMS: 10_000,
+		})
+	}
+
+	for i := range maxConcurrentJobsPerKey + maxQueuedJobsPerKey {
+		resp := submit("alpha", i)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+		var job struct {
+			ID string `json:"id"`
+		}
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+		ids = append(ids, job.ID)
+	}
+
+	require.Eventually(t, func() bool {
+		return inFlight("alpha") == maxConcurrentJobsPerKey
+	}, time.Second, 20*time.Millisecond)
+
+	resp := submit("alpha", maxConcurrentJobsPerKey+maxQueuedJobsPerKey)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// очередь другого ключа не зависит от alpha
+	resp = submit("beta", 0)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	require.Eventually(t, func() bool {
+		return inFlight("beta") == 1
+	}, time.Second, 20*time.Millisecond)
+
+	require.Never(t, func() bool {
+		return inFlight("alpha") > maxConcurrentJobsPerKey
+	}, 200*time.Millisecond, 20*time.Millisecond)
+
+	release()
+
+	for _, id := range ids {
+		events := waitJob(t, c, baseUrl, id)
+		require.Equal(t, "done", events[len(events)-1].Name)
+	}
+}
+
+func TestCrawlQueueAnonymousKey(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, inFlight, release := newGateServer(t)
+
+	for i := range maxConcurrentJobsPerKey + maxQueuedJobsPerKey {
+		submitJob(t, c, baseUrl, CrawlRequest{
+			URLs:      makeURLs(t, srv.URL+"/anonymous/job-"+string(rune('a'+i)), 1),
+			Workers:   1,
+			TimeoutMS: 10_000,
+		})
+	}
+
+	require.Eventually(t, func() bool {
+		return inFlight("anonymous") == maxConcurrentJobsPerKey
+	}, time.Second, 20*time.Millisecond)
+
+	// синхронный /crawl стоит в той же очереди, что и задачи
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL+"/anonymous/sync", 1),
+		Workers:   1,
+		TimeoutMS: 10_000,
+	})
+
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	release()
+}
+
+// newStepServer держит каждый запрос, пока его путь не отпустят через release, и запоминает порядок прихода
+func newStepServer(t *testing.T) (srv *httptest.Server, started func() []string, release func(path string)) {
+	t.Helper()
+
+	var (
+		mu    sync.Mutex
+		order []string
+		gates = make(map[string]chan struct{})
+	)
+
+	gate := func(path string) chan struct{} {
+		mu.Lock()
+		defer mu.Unlock()
+
+		ch, ok := gates[path]
+		if !ok {
+			ch = make(chan struct{})
+			gates[path] = ch
+		}
+
+		return ch
+	}
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		order = append(order, r.URL.Path)
+		mu.Unlock()
+
+		select {
+		case <-gate(r.URL.Path):
+		case <-r.Context().Done():
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	released := make(map[string]bool)
+	release = func(path string) {
+		ch := gate(path)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !released[path] {
+			released[path] = true
+			close(ch)
+		}
+	}
+
+	t.Cleanup(func() {
+		mu.Lock()
+		paths := make([]string, 0, len(gates))
+		for path := range gates {
+			paths = append(paths, path)
+		}
+		mu.Unlock()
+
+		for _, path := range paths {
+			release(path)
+		}
+
+		srv.Close()
+	})
+
+	started = func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string(nil), order...)
+	}
+
+	return srv, started, release
+}
+
+// stepJobPath - путь единственного урла задачи i ключа key на newStepServer
+func stepJobPath(key string, i int) string {
+	return "/" + key + "/job-" + strconv.Itoa(i) + "/item-0"
+}
+
+func submitStepJob(t *testing.T, c *http.Client, baseURL *url.URL, srv *httptest.Server, key string, i int) {
+	t.Helper()
+
+	resp := postWithKey(t, c, constructJobsPath(t, baseURL), key, CrawlRequest{
+		URLs:      []string{srv.URL + stepJobPath(key, i)},
+		Workers:   1,
+		TimeoutMS: 30_000,
+	})
+
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+}
+
+// waitStarted дожидается ровно n запросов на newStepServer
+func waitStarted(t *testing.T, started func() []string, n int) []string {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return len(started()) >= n
+	}, 2*time.Second, 10*time.Millisecond)
+
+	got := started()
+	require.Len(t, got, n)
+
+	return got
+}
+
+func setAPIKeyWeights(t *testing.T, weights map[string]int) {
+	t.Helper()
+
+	prev := apiKeyWeights
+	apiKeyWeights = weights
+
+	t.Cleanup(func() {
+		apiKeyWeights = prev
+	})
+}
+
+func TestJobQueueFIFOWithinKey(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, started, release := newStepServer(t)
+
+	const total = maxConcurrentJobsPerKey + maxQueuedJobsPerKey
+
+	for i := range total {
+		submitStepJob(t, c, baseUrl, srv, "fifo", i)
+	}
+
+	running := waitStarted(t, started, maxConcurrentJobsPerKey)
+	require.ElementsMatch(t, []string{stepJobPath("fifo", 0), stepJobPath("fifo", 1)}, running)
+
+	// каждый освободившийся слот достаётся следующей по порядку поступления задаче
+	for i := maxConcurrentJobsPerKey; i < total; i++ {
+		release(stepJobPath("fifo", i-maxConcurrentJobsPerKey))
+
+		got := waitStarted(t, started, i+1)
+		require.Equal(t, stepJobPath("fifo", i), got[i])
+	}
+}
+
+func TestJobQueueGlobalCap(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, started, release := newStepServer(t)
+
+	// ключей больше, чем нужно, чтобы упереться в maxConcurrentJobs раньше, чем в лимиты ключей
+	keys := maxConcurrentJobs/maxConcurrentJobsPerKey + 1
+	for k := range keys {
+		for i := range maxConcurrentJobsPerKey {
+			submitStepJob(t, c, baseUrl, srv, "cap-"+strconv.Itoa(k), i)
+		}
+	}
+
+	first := waitStarted(t, started, maxConcurrentJobs)
+
+	require.Never(t, func() bool {
+		return len(started()) > maxConcurrentJobs
+	}, 200*time.Millisecond, 20*time.Millisecond)
+
+	// освободившийся слот сразу занимает ожидающая задача
+	release(first[0])
+	waitStarted(t, started, maxConcurrentJobs+1)
+}
+
+func TestJobQueueWeightedFairness(t *testing.T) {
+	setAPIKeyWeights(t, map[string]int{"heavy": 2})
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, started, release := newStepServer(t)
+
+	// фоновые ключи занимают все maxConcurrentJobs слотов
+	for k := range maxConcurrentJobs / maxConcurrentJobsPerKey {
+		for i := range maxConcurrentJobsPerKey {
+			submitStepJob(t, c, baseUrl, srv, "filler-"+strconv.Itoa(k), i)
+		}
+	}
+
+	fillers := waitStarted(t, started, maxConcurrentJobs)
+
+	for i := range maxQueuedJobsPerKey {
+		submitStepJob(t, c, baseUrl, srv, "heavy", i)
+		submitStepJob(t, c, baseUrl, srv, "light", i)
+	}
+
+	// по одному освобождаем слот и смотрим, кому он достался; запущенную задачу сразу отпускаем,
+	// чтобы лимит на ключ не вмешивался в распределение
+	const grants = 6
+
+	counts := make(map[string]int)
+	next := fillers[0]
+
+	for g := range grants {
+		release(next)
+
+		got := waitStarted(t, started, maxConcurrentJobs+g+1)
+		next = got[len(got)-1]
+
+		counts[strings.SplitN(strings.TrimPrefix(next, "/"), "/", 2)[0]]++
+	}
+
+	// взвешенный round-robin 2:1 за два полных круга
+	require.Equal(t, map[string]int{"heavy": 4, "light": 2}, counts)
+}
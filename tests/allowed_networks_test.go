@@ -0,0 +1,137 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	allowedNetworksEnv      = "CRAWLER_ALLOWED_NETWORKS"
+	errorCodeHostNotAllowed = "host_not_allowed"
+)
+
+func TestCrawlAllowedNetworksPinning(t *testing.T) {
+	t.Setenv(allowedNetworksEnv, "127.0.0.0/8")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var hosts atomic.Value
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts.Store(r.Host)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	byName := "http://localhost:" + u.Port() + "/by-name"
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/by-ip", byName},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 2)
+
+	for i := range got {
+		require.Empty(t, got[i].Error)
+		require.Equal(t, http.StatusNoContent, got[i].StatusCode)
+		require.Equal(t, "127.0.0.1", got[i].PinnedIP)
+	}
+
+	// соединение идёт на проверенный адрес, но Host остаётся исходным
+	require.Equal(t, "localhost:"+u.Port(), hosts.Load())
+}
+
+func TestCrawlAllowedNetworksDenied(t *testing.T) {
+	t.Setenv(allowedNetworksEnv, "10.0.0.0/8,192.168.0.0/16")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var hits atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, errorCodeHostNotAllowed, got[0].ErrorCode)
+	require.Zero(t, got[0].StatusCode)
+	require.Empty(t, got[0].PinnedIP)
+	require.Zero(t, hits.Load())
+}
+
+func TestCrawlAllowedNetworksRedirect(t *testing.T) {
+	t.Setenv(allowedNetworksEnv, "127.0.0.0/8")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://10.255.255.1/internal", http.StatusFound)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, errorCodeHostNotAllowed, got[0].ErrorCode)
+}
+
+func TestCrawlWithoutAllowedNetworks(t *testing.T) {
+	t.Setenv(allowedNetworksEnv, "")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+	require.Empty(t, got[0].PinnedIP)
+}
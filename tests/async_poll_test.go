@@ -0,0 +1,217 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const asyncPath = "async"
+
+type asyncJobStatus struct {
+	ID       string      `json:"id"`
+	Status   string      `json:"status"`
+	Progress sseProgress `json:"progress"`
+	Results  []sseResult `json:"results"`
+}
+
+func submitAsync(t *testing.T, c *http.Client, baseURL *url.URL, body any) (id string, location string) {
+	t.Helper()
+
+	reqBody, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	resp, err := c.Post(baseURL.JoinPath(crawlPath, asyncPath).String(), contentTypeJson, bytes.NewReader(reqBody))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var job struct {
+		ID string `json:"id"`
+	}
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+	require.NotEmpty(t, job.ID)
+
+	return job.ID, resp.Header.Get("Location")
+}
+
+// readAsync не вызывает require, поэтому годится и для условий require.Eventually
+func readAsync(c *http.Client, baseURL *url.URL, id string) (asyncJobStatus, *http.Response, error) {
+	var st asyncJobStatus
+
+	resp, err := c.Get(baseURL.JoinPath(crawlPath, jobsPath, id).String())
+	if err != nil {
+		return st, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		err = json.NewDecoder(resp.Body).Decode(&st)
+	}
+
+	return st, resp, err
+}
+
+func pollAsync(t *testing.T, c *http.Client, baseURL *url.URL, id string) (asyncJobStatus, *http.Response) {
+	t.Helper()
+
+	st, resp, err := readAsync(c, baseURL, id)
+	require.NoError(t, err)
+
+	return st, resp
+}
+
+func TestCrawlAsyncPolling(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	gate := make(chan struct{})
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			close(gate)
+		})
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/slow") {
+			select {
+			case <-gate:
+			case <-r.Context().Done():
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(func() {
+		release()
+		srv.Close()
+	})
+
+	const n = 6
+
+	urls := append(makeURLs(t, srv.URL, n), makeURLs(t, srv.URL+"/slow", n)...)
+
+	id, location := submitAsync(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   n,
+		TimeoutMS: 10_000,
+	})
+
+	require.Equal(t, "/crawl/jobs/"+id, location)
+
+	// быстрая половина готова, медленная ждёт gate
+	var st asyncJobStatus
+	require.Eventually(t, func() bool {
+		var (
+			resp *http.Response
+			err  error
+		)
+
+		st, resp, err = readAsync(c, baseUrl, id)
+		return err == nil && resp.StatusCode == http.StatusOK && len(st.Results) == n
+	}, 2*time.Second, 20*time.Millisecond)
+
+	require.Equal(t, id, st.ID)
+	require.Equal(t, "running", st.Status)
+	require.Equal(t, n, st.Progress.Done)
+	require.Equal(t, len(urls), st.Progress.Total)
+
+	for i, r := range st.Results {
+		require.Equal(t, i, r.Index)
+		require.Equal(t, urls[i], r.URL)
+	}
+
+	_, resp := pollAsync(t, c, baseUrl, id)
+	require.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	release()
+
+	require.Eventually(t, func() bool {
+		var err error
+
+		st, resp, err = readAsync(c, baseUrl, id)
+		return err == nil && st.Status == "done"
+	}, 2*time.Second, 20*time.Millisecond)
+
+	require.Empty(t, resp.Header.Get("Retry-After"))
+	require.Equal(t, len(urls), st.Progress.Done)
+	require.Len(t, st.Results, len(urls))
+
+	for i, r := range st.Results {
+		require.Equal(t, i, r.Index)
+		require.Equal(t, urls[i], r.URL)
+		require.Empty(t, r.Error)
+		require.Equal(t, http.StatusNoContent, r.StatusCode)
+	}
+}
+
+func TestCrawlAsyncSharesJobs(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	urls := makeURLs(t, srv.URL, 3)
+
+	id, _ := submitAsync(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	events := waitJob(t, c, baseUrl, id)
+	require.NotEmpty(t, events)
+	require.Equal(t, "done", events[len(events)-1].Name)
+
+	st, resp := pollAsync(t, c, baseUrl, id)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "done", st.Status)
+	require.Len(t, st.Results, len(urls))
+
+	// и наоборот: задача из /jobs видна через опрос
+	id = submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	require.Eventually(t, func() bool {
+		var err error
+
+		st, resp, err = readAsync(c, baseUrl, id)
+		return err == nil && resp.StatusCode == http.StatusOK && st.Status == "done"
+	}, 2*time.Second, 20*time.Millisecond)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, st.Results, len(urls))
+}
+
+func TestCrawlAsyncUnknownJob(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	_, resp := pollAsync(t, client(), baseUrl, "unknown")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,137 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const autoTimeout = "auto"
+
+func newLatencyServer(t *testing.T, slow time.Duration) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/slow") {
+			select {
+			case <-time.After(slow):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAutoTimeout(t *testing.T) {
+	t.Setenv(jobHistoryEnv, "1")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	const slow = 4 * autoTimeoutFloor
+	srv := newLatencyServer(t, slow)
+
+	// без истории действует потолок, и медленный урл успевает ответить
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/slow/cold"},
+		Workers:   1,
+		TimeoutMS: 5000,
+		Timeout:   autoTimeout,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL+"/fast", 3*autoTimeoutMinSamples),
+		Workers:   4,
+		TimeoutMS: 5000,
+	})
+
+	for _, r := range got {
+		require.Equal(t, http.StatusOK, r.StatusCode)
+	}
+
+	// теперь история говорит, что хост отвечает за миллисекунды: таймаут прижат к autoTimeoutFloor
+	start := time.Now()
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/slow/warm", srv.URL + "/fast/warm"},
+		Workers:   2,
+		TimeoutMS: 5000,
+		Timeout:   autoTimeout,
+	})
+
+	require.Less(t, time.Since(start), slow)
+	require.Len(t, got, 2)
+
+	require.Zero(t, got[0].StatusCode)
+	require.Equal(t, errorCodeTimeout, got[0].ErrorCode)
+	require.Equal(t, http.StatusOK, got[1].StatusCode)
+
+	// без "auto" действует только timeout_ms
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/slow/manual"},
+		Workers:   1,
+		TimeoutMS: 5000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+}
+
+func TestAutoTimeoutWithoutHistory(t *testing.T) {
+	t.Setenv(jobHistoryEnv, "")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newLatencyServer(t, 4*autoTimeoutFloor)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL+"/fast", 2*autoTimeoutMinSamples),
+		Workers:   4,
+		TimeoutMS: 5000,
+	})
+
+	require.Len(t, got, 2*autoTimeoutMinSamples)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/slow"},
+		Workers:   1,
+		TimeoutMS: 5000,
+		Timeout:   autoTimeout,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+}
+
+func TestAutoTimeoutValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://127.0.0.1/"},
+		Workers:   1,
+		TimeoutMS: 1000,
+		Timeout:   "5s",
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
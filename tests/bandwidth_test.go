@@ -0,0 +1,161 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const bandwidthEnv = "CRAWLER_BANDWIDTH_BPS"
+
+func newPayloadServer(t *testing.T, size int) *httptest.Server {
+	t.Helper()
+
+	payload := bytes.Repeat([]byte("x"), size)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(size))
+		_, _ = w.Write(payload)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestBandwidthLimit(t *testing.T) {
+	const (
+		limit = 1 << 20
+		size  = 64 << 10
+		n     = 24
+	)
+
+	t.Setenv(bandwidthEnv, strconv.Itoa(limit))
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newPayloadServer(t, size)
+
+	start := time.Now()
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL, n),
+		Workers:   8,
+		TimeoutMS: 30_000,
+	})
+	delay := time.Since(start)
+
+	require.Len(t, got, n)
+	for _, r := range got {
+		require.Empty(t, r.Error, r.URL)
+		require.Equal(t, http.StatusOK, r.StatusCode, r.URL)
+	}
+
+	// 1.5MB при лимите 1MB/s и всплеске 100ms
+	require.Greater(t, delay, 1300*time.Millisecond)
+
+	m := scrapeMetrics(t, c, baseUrl)
+	require.InDelta(t, float64(limit), m["crawler_bandwidth_limit_bytes_per_second"], 0)
+	require.GreaterOrEqual(t, m["crawler_bandwidth_bytes_total"], float64(n*size))
+	require.Positive(t, m["crawler_bandwidth_usage_bytes_per_second"])
+	require.LessOrEqual(t, m["crawler_bandwidth_usage_bytes_per_second"], 1.2*limit)
+}
+
+func TestBandwidthWeights(t *testing.T) {
+	const (
+		limit = 1 << 20
+		size  = 64 << 10
+		n     = 16
+	)
+
+	t.Setenv(bandwidthEnv, strconv.Itoa(limit))
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	heavy := newPayloadServer(t, size)
+	light := newPayloadServer(t, size)
+
+	crawlWeighted := func(srv *httptest.Server, weight int) <-chan crawlResult {
+		return crawlAsync(c, baseUrl, CrawlRequest{
+			URLs:            makeURLs(t, srv.URL, n),
+			Workers:         4,
+			TimeoutMS:       30_000,
+			BandwidthWeight: weight,
+		})
+	}
+
+	start := time.Now()
+	heavyDone, lightDone := crawlWeighted(heavy, 3), crawlWeighted(light, 1)
+
+	// время завершения каждого обхода фиксируется в момент, когда пришёл именно его ответ
+	var finished [2]time.Duration
+	for range 2 {
+		var (
+			res crawlResult
+			i   int
+		)
+
+		select {
+		case res = <-heavyDone:
+			heavyDone, i = nil, 0
+		case res = <-lightDone:
+			lightDone, i = nil, 1
+		}
+
+		finished[i] = time.Since(start)
+
+		require.NoError(t, res.err)
+		require.Equal(t, http.StatusOK, res.status)
+		require.Len(t, res.results, n)
+	}
+
+	// при равных долях обходы закончились бы одновременно (~2s), с весами 3:1 тяжёлый - за ~1.33s
+	require.Less(t, finished[0], finished[1]-300*time.Millisecond)
+}
+
+func TestBandwidthValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, weight := range []int{-1, maxBandwidthWeight + 1} {
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{
+			URLs:            []string{"http://127.0.0.1/"},
+			Workers:         1,
+			TimeoutMS:       1000,
+			BandwidthWeight: weight,
+		})
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, weight)
+	}
+
+	// без лимита трафик всё равно считается
+	srv := newPayloadServer(t, 1<<10)
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:            []string{srv.URL},
+		Workers:         1,
+		TimeoutMS:       2000,
+		BandwidthWeight: maxBandwidthWeight,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+
+	m := scrapeMetrics(t, c, baseUrl)
+	require.Zero(t, m["crawler_bandwidth_limit_bytes_per_second"])
+	require.GreaterOrEqual(t, m["crawler_bandwidth_bytes_total"], float64(1<<10))
+
+	t.Setenv(bandwidthEnv, "fast")
+
+	requireStartError(t, New())
+}
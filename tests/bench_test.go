@@ -0,0 +1,216 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const benchPath = "/admin/bench"
+
+type benchRequest struct {
+	URLs            int   `json:"urls"`
+	LatencyMS       int   `json:"latency_ms"`
+	LatencyJitterMS int   `json:"latency_jitter_ms,omitempty"`
+	Seed            int64 `json:"seed,omitempty"`
+	Workers         int   `json:"workers"`
+	Concurrency     int   `json:"concurrency,omitempty"`
+}
+
+type benchReport struct {
+	URLs           int     `json:"urls"`
+	Errors         int     `json:"errors"`
+	DurationMS     float64 `json:"duration_ms"`
+	URLsPerSec     float64 `json:"urls_per_sec"`
+	P50MS          float64 `json:"p50_ms"`
+	P99MS          float64 `json:"p99_ms"`
+	JitterMS       int64   `json:"jitter_ms"`
+	GoroutinesPeak int     `json:"goroutines_peak"`
+	Allocs         uint64  `json:"allocs"`
+	AllocBytes     uint64  `json:"alloc_bytes"`
+}
+
+// postBench не вызывает require, поэтому годится для горутин и условий require.Eventually
+func postBench(c *http.Client, baseURL *url.URL, req benchRequest) (int, benchReport, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return 0, benchReport{}, err
+	}
+
+	resp, err := c.Post(baseURL.JoinPath(benchPath).String(), contentTypeJson, bytes.NewReader(body))
+	if err != nil {
+		return 0, benchReport{}, err
+	}
+	defer resp.Body.Close()
+
+	var report benchReport
+	if resp.StatusCode == http.StatusOK {
+		err = json.NewDecoder(resp.Body).Decode(&report)
+	}
+
+	return resp.StatusCode, report, err
+}
+
+func runBench(t *testing.T, c *http.Client, baseURL *url.URL, req benchRequest) (int, benchReport) {
+	t.Helper()
+
+	code, report, err := postBench(c, baseURL, req)
+	require.NoError(t, err)
+
+	return code, report
+}
+
+func TestAdminBench(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	const (
+		n       = 200
+		latency = 20 * time.Millisecond
+	)
+
+	code, report := runBench(t, c, baseUrl, benchRequest{
+		URLs:        n,
+		LatencyMS:   int(latency.Milliseconds()),
+		Workers:     25,
+		Concurrency: 2,
+	})
+
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, n, report.URLs)
+	require.Zero(t, report.Errors)
+
+	// 200 урлов по 20ms на 2*25 воркерах - не меньше 4 волн
+	require.GreaterOrEqual(t, report.DurationMS, float64(4*latency.Milliseconds()))
+	require.InDelta(t, float64(n)/report.DurationMS*1000, report.URLsPerSec, report.URLsPerSec*0.05)
+
+	require.GreaterOrEqual(t, report.P50MS, float64(latency.Milliseconds()))
+	require.GreaterOrEqual(t, report.P99MS, report.P50MS)
+	require.GreaterOrEqual(t, report.GoroutinesPeak, 2*25)
+	require.Positive(t, report.Allocs)
+	require.Positive(t, report.AllocBytes)
+
+	// урлы каждого прогона новые: второй прогон не быстрее за счёт кэша
+	code, again := runBench(t, c, baseUrl, benchRequest{
+		URLs:        n,
+		LatencyMS:   int(latency.Milliseconds()),
+		Workers:     25,
+		Concurrency: 2,
+	})
+
+	require.Equal(t, http.StatusOK, code)
+	require.GreaterOrEqual(t, again.DurationMS, float64(4*latency.Milliseconds()))
+}
+
+func TestAdminBenchConflict(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	type outcome struct {
+		code int
+		err  error
+	}
+
+	done := make(chan outcome, 1)
+	go func() {
+		code, _, err := postBench(c, baseUrl, benchRequest{
+			URLs:      10,
+			LatencyMS: 500,
+			Workers:   1,
+		})
+
+		done <- outcome{code: code, err: err}
+	}()
+
+	require.Eventually(t, func() bool {
+		code, _, err := postBench(c, baseUrl, benchRequest{URLs: 1, Workers: 1})
+		return err == nil && code == http.StatusConflict
+	}, 2*time.Second, 20*time.Millisecond)
+
+	first := <-done
+	require.NoError(t, first.err)
+	require.Equal(t, http.StatusOK, first.code)
+}
+
+func TestAdminBenchSeed(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	run := func(seed int64, workers, concurrency int) benchReport {
+		code, report := runBench(t, c, baseUrl, benchRequest{
+			URLs:            40,
+			LatencyJitterMS: 50,
+			Seed:            seed,
+			Workers:         workers,
+			Concurrency:     concurrency,
+		})
+
+		require.Equal(t, http.StatusOK, code)
+		require.Zero(t, report.Errors)
+
+		return report
+	}
+
+	first := run(42, 8, 1)
+	require.Positive(t, first.JitterMS)
+	require.LessOrEqual(t, first.JitterMS, int64(40*50))
+
+	// тот же seed - та же последовательность задержек, как бы ни делились урлы
+	require.Equal(t, first.JitterMS, run(42, 8, 1).JitterMS)
+	require.Equal(t, first.JitterMS, run(42, 3, 4).JitterMS)
+
+	require.NotEqual(t, first.JitterMS, run(43, 8, 1).JitterMS)
+}
+
+func TestAdminBenchValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, req := range []benchRequest{
+		{URLs: 0, Workers: 1},
+		{URLs: maxBenchURLs + 1, Workers: 1},
+		{URLs: 1, Workers: 0},
+		{URLs: 1, Workers: 1, Concurrency: maxBenchConcurrency + 1},
+		{URLs: 1, Workers: 1, LatencyMS: -1},
+		{URLs: 1, Workers: 1, LatencyJitterMS: -1},
+		{URLs: 1, Workers: 1, LatencyMS: int(maxBenchLatency.Milliseconds()) + 1},
+	} {
+		code, _ := runBench(t, c, baseUrl, req)
+		require.Equal(t, http.StatusBadRequest, code, "%+v", req)
+	}
+}
+
+func TestAdminBenchRequiresAdmin(t *testing.T) {
+	setAPIKeyRoles(t, map[string]string{
+		"s": roleSubmitter,
+		"a": roleAdmin,
+	})
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	target := baseUrl.JoinPath(benchPath)
+	req := benchRequest{URLs: 1, Workers: 1}
+
+	resp := postWithKey(t, c, target, "s", req)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = postWithKey(t, c, target, "a", req)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
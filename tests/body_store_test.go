@@ -0,0 +1,195 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func storedFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	return names
+}
+
+func readStored(t *testing.T, s *bodyStore, key string) string {
+	t.Helper()
+
+	r, err := s.get(key)
+	require.NoError(t, err)
+	defer r.Close()
+
+	body, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestBodyStoreDeduplication(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := newBodyStore(dir)
+	require.NoError(t, err)
+
+	const (
+		template = "<html>same template</html>"
+		unique   = "<html>unique</html>"
+	)
+
+	for i := range 3 {
+		hash, err := s.put("https://example.com/"+strconv.Itoa(i), strings.NewReader(template))
+		require.NoError(t, err)
+		require.Equal(t, sha256Hex(template), hash)
+	}
+
+	require.Equal(t, []string{sha256Hex(template)}, storedFiles(t, dir))
+
+	_, err = s.put("https://example.com/unique", strings.NewReader(unique))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{sha256Hex(template), sha256Hex(unique)}, storedFiles(t, dir))
+
+	require.Equal(t, template, readStored(t, s, "https://example.com/1"))
+	require.Equal(t, unique, readStored(t, s, "https://example.com/unique"))
+
+	// новое тело ключа освобождает старое
+	_, err = s.put("https://example.com/unique", strings.NewReader(template))
+	require.NoError(t, err)
+	require.Equal(t, []string{sha256Hex(template)}, storedFiles(t, dir))
+
+	for _, key := range []string{"https://example.com/0", "https://example.com/1", "https://example.com/2"} {
+		require.NoError(t, s.remove(key))
+		require.Equal(t, []string{sha256Hex(template)}, storedFiles(t, dir))
+	}
+
+	require.NoError(t, s.remove("https://example.com/unique"))
+	require.Empty(t, storedFiles(t, dir))
+
+	_, err = s.get("https://example.com/unique")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestBodyStoreConcurrentPut(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := newBodyStore(dir)
+	require.NoError(t, err)
+
+	const (
+		n    = 64
+		body = "<html>shared</html>"
+	)
+
+	var wg sync.WaitGroup
+
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, errs[i] = s.put("https://example.com/"+strconv.Itoa(i), strings.NewReader(body))
+		}()
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []string{sha256Hex(body)}, storedFiles(t, dir))
+
+	for i := range n {
+		require.Equal(t, body, readStored(t, s, "https://example.com/"+strconv.Itoa(i)))
+	}
+
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.remove("https://example.com/" + strconv.Itoa(i))
+		}()
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.Empty(t, storedFiles(t, dir))
+}
+
+func TestCrawlStoresBodies(t *testing.T) {
+	const (
+		template = "<html>same template</html>"
+		unique   = "<html>unique</html>"
+	)
+
+	dir := t.TempDir()
+	t.Setenv("CRAWLER_BODY_DIR", dir)
+
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unique" {
+			io.WriteString(w, unique)
+			return
+		}
+
+		io.WriteString(w, template)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	urls := append(makeURLs(t, srv.URL, 3), srv.URL+"/unique")
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   4,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, len(urls))
+	for i := range got {
+		require.Equal(t, http.StatusOK, got[i].StatusCode)
+	}
+
+	// одинаковые тела разных урлов лежат в одном файле
+	require.ElementsMatch(t, []string{sha256Hex(template), sha256Hex(unique)}, storedFiles(t, dir))
+
+	for _, body := range []string{template, unique} {
+		stored, err := os.ReadFile(filepath.Join(dir, sha256Hex(body)))
+		require.NoError(t, err)
+		require.Equal(t, body, string(stored))
+	}
+
+	// истёкшие записи кэша освобождают свои тела
+	require.Eventually(t, func() bool {
+		clk.Advance(cacheTTL)
+
+		entries, err := os.ReadDir(dir)
+		return err == nil && len(entries) == 0
+	}, 2*time.Second, 20*time.Millisecond)
+}
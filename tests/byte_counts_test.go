@@ -0,0 +1,162 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bytesHeadersHeader = "X-Bytes-Headers"
+	bytesBodyHeader    = "X-Bytes-Body"
+
+	padSize  = 1000
+	pageSize = 4096
+)
+
+func newPaddedServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	pad := strings.Repeat("p", padSize)
+	body := bytes.Repeat([]byte("b"), pageSize)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/page", http.StatusFound)
+			return
+		}
+
+		w.Header().Set("X-Pad", pad)
+		w.Header().Set("Content-Length", strconv.Itoa(pageSize))
+		_, _ = w.Write(body)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCrawlByteCounts(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newPaddedServer(t)
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:       []string{srv.URL + "/page", srv.URL + "/redirect"},
+		Workers:    1,
+		TimeoutMS:  2000,
+		CountBytes: true,
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Trailer, bytesHeadersHeader)
+	require.Contains(t, resp.Trailer, bytesBodyHeader)
+
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var got []CrawlResponse
+	require.NoError(t, json.Unmarshal(raw, &got))
+	require.Len(t, got, 2)
+
+	page, redirect := got[0].Bytes, got[1].Bytes
+	require.NotNil(t, page)
+	require.NotNil(t, redirect)
+
+	require.EqualValues(t, pageSize, page.Body)
+	require.Greater(t, page.Headers, int64(padSize))
+	require.Less(t, page.Headers, int64(padSize+500))
+
+	// redirect добавляет свои заголовки и тело
+	require.Greater(t, redirect.Headers, page.Headers)
+	require.Greater(t, redirect.Body, page.Body)
+
+	require.Equal(t, strconv.FormatInt(page.Headers+redirect.Headers, 10), resp.Trailer.Get(bytesHeadersHeader))
+	require.Equal(t, strconv.FormatInt(page.Body+redirect.Body, 10), resp.Trailer.Get(bytesBodyHeader))
+
+	// из кэша ничего не передаётся
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:       []string{srv.URL + "/page"},
+		Workers:    1,
+		TimeoutMS:  2000,
+		CountBytes: true,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, &ByteCounts{}, got[0].Bytes)
+}
+
+func TestJobByteCounts(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newPaddedServer(t)
+
+	const n = 3
+
+	events := waitJob(t, c, baseUrl, submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:       makeURLs(t, srv.URL, n),
+		Workers:    2,
+		TimeoutMS:  2000,
+		Mode:       "head",
+		CountBytes: true,
+	}))
+
+	require.NotEmpty(t, events)
+
+	var total ByteCounts
+	for _, e := range events {
+		if e.Name != "result" {
+			continue
+		}
+
+		var r sseResult
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &r))
+		require.NotNil(t, r.Bytes, r.URL)
+		require.Zero(t, r.Bytes.Body, r.URL)
+		require.Greater(t, r.Bytes.Headers, int64(padSize), r.URL)
+
+		total.Headers += r.Bytes.Headers
+	}
+
+	last := events[len(events)-1]
+	require.Equal(t, "done", last.Name)
+
+	var done struct {
+		Bytes *ByteCounts `json:"bytes"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(last.Data), &done))
+	require.Equal(t, &total, done.Bytes)
+}
+
+func TestCrawlByteCountsDisabled(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newPaddedServer(t)
+
+	events := waitJob(t, c, baseUrl, submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/page"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	}))
+
+	require.NotEmpty(t, events)
+
+	for _, e := range events {
+		require.NotContains(t, e.Data, `"bytes"`, e.Name)
+	}
+}
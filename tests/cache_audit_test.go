@@ -0,0 +1,143 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const cacheAuditFileEnv = "CRAWLER_CACHE_AUDIT_FILE"
+
+type cacheAuditEvent struct {
+	Event string    `json:"event"`
+	Key   string    `json:"key"`
+	AgeMS float64   `json:"age_ms"`
+	Hits  int       `json:"hits"`
+	Time  time.Time `json:"time"`
+}
+
+// setResponseCacheSize ограничивает кэш ответов. Вызывать до старта краулера.
+func setResponseCacheSize(t *testing.T, size int) {
+	t.Helper()
+
+	prev := responseCacheSize
+	responseCacheSize = size
+
+	t.Cleanup(func() {
+		responseCacheSize = prev
+	})
+}
+
+// loadCacheAudit не вызывает require, поэтому годится и для условий require.Eventually
+func loadCacheAudit(path string) ([]cacheAuditEvent, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []cacheAuditEvent
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e cacheAuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	return events, scanner.Err()
+}
+
+func readCacheAudit(t *testing.T, path string) []cacheAuditEvent {
+	t.Helper()
+
+	events, err := loadCacheAudit(path)
+	require.NoError(t, err)
+
+	return events
+}
+
+func TestCacheAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache-audit.jsonl")
+	t.Setenv(cacheAuditFileEnv, path)
+	setResponseCacheSize(t, 2)
+
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	key := func(raw string) string {
+		normalized, err := normalizeURL(raw)
+		require.NoError(t, err)
+		return normalized
+	}
+
+	crawlOne := func(raw string) {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{raw},
+			Workers:   1,
+			TimeoutMS: 2000,
+		})
+
+		require.Len(t, got, 1)
+		require.Equal(t, http.StatusOK, got[0].StatusCode)
+	}
+
+	a, b, d := srv.URL+"/a/200", srv.URL+"/b/200", srv.URL+"/d/200"
+
+	crawlOne(a)
+	crawlOne(b)
+
+	clk.Advance(cacheTTL / 4)
+	crawlOne(a) // попадание: a становится самой свежей
+	crawlOne(d) // вытесняет b
+
+	require.Eventually(t, func() bool {
+		events, err := loadCacheAudit(path)
+		return err == nil && len(events) == 1
+	}, time.Second, 20*time.Millisecond)
+
+	evicted := readCacheAudit(t, path)[0]
+	require.Equal(t, "evict", evicted.Event)
+	require.Equal(t, key(b), evicted.Key)
+	require.Zero(t, evicted.Hits)
+	require.InDelta(t, float64((cacheTTL / 4).Milliseconds()), evicted.AgeMS, 50)
+	require.WithinDuration(t, clk.Now(), evicted.Time, time.Second)
+
+	clk.Advance(cacheTTL + cacheTTL/4)
+
+	require.Eventually(t, func() bool {
+		events, err := loadCacheAudit(path)
+		return err == nil && len(events) == 3
+	}, time.Second, 20*time.Millisecond)
+
+	expired := make(map[string]cacheAuditEvent)
+	for _, e := range readCacheAudit(t, path)[1:] {
+		require.Equal(t, "expire", e.Event)
+		expired[e.Key] = e
+	}
+
+	require.Contains(t, expired, key(a))
+	require.Contains(t, expired, key(d))
+	require.Equal(t, 1, expired[key(a)].Hits)
+	require.Zero(t, expired[key(d)].Hits)
+	require.GreaterOrEqual(t, expired[key(a)].AgeMS, float64(cacheTTL.Milliseconds()))
+}
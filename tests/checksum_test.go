@@ -0,0 +1,87 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const contentSHA256Header = "X-Content-SHA256"
+
+func TestCrawlChecksumTrailer(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/200", srv.URL + "/404", "http://127.0.0.1:1/"},
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// ключи Trailer хранятся в канонической форме: X-Content-Sha256
+	require.Contains(t, resp.Trailer, http.CanonicalHeaderKey(contentSHA256Header))
+
+	// значение трейлера появляется только после того, как тело дочитано
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(body)
+	require.Equal(t, hex.EncodeToString(sum[:]), resp.Trailer.Get(contentSHA256Header))
+}
+
+func TestJobChecksum(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	urls := []string{srv.URL + "/200", srv.URL + "/500", srv.URL + "/204"}
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	events := waitJob(t, c, baseUrl, id)
+	require.NotEmpty(t, events)
+
+	results := make([]CrawlResponse, len(urls))
+	for _, e := range events {
+		if e.Name != "result" {
+			continue
+		}
+
+		var r sseResult
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &r))
+		results[r.Index] = r.CrawlResponse
+	}
+
+	last := events[len(events)-1]
+	require.Equal(t, "done", last.Name)
+
+	var done struct {
+		SHA256 string `json:"sha256"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(last.Data), &done))
+
+	payload, err := json.Marshal(results)
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(payload)
+	require.Equal(t, hex.EncodeToString(sum[:]), done.SHA256)
+}
@@ -0,0 +1,232 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	breakerThresholdEnv = "CRAWLER_BREAKER_THRESHOLD"
+	breakerCooldownEnv  = "CRAWLER_BREAKER_COOLDOWN_MS"
+	circuitOpenError    = "circuit open"
+)
+
+func TestCircuitBreakerFastFailsBatch(t *testing.T) {
+	t.Setenv(breakerThresholdEnv, "2")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	dead := closedServerURL(t)
+
+	const n = 6
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, dead.String(), n),
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, n)
+
+	for i, r := range got {
+		if i < 2 {
+			require.Equal(t, errorCodeFetchFailed, r.ErrorCode, r.URL)
+			continue
+		}
+
+		require.Equal(t, errorCodeHostQuarantined, r.ErrorCode, r.URL)
+		require.Equal(t, circuitOpenError, r.Error, r.URL)
+	}
+
+	entries := getQuarantine(t, c, baseUrl)
+	require.Len(t, entries, 1)
+	require.Equal(t, dead.Host, entries[0].Host)
+
+	m := scrapeMetrics(t, c, baseUrl)
+	require.InDelta(t, 1, m["crawler_circuit_open_hosts"], 0)
+	require.InDelta(t, 1, m["crawler_circuit_opened_total"], 0)
+}
+
+func TestCircuitBreakerCountsAutoTimeouts(t *testing.T) {
+	t.Setenv(breakerThresholdEnv, "2")
+	t.Setenv(jobHistoryEnv, "1")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	const slow = 4 * autoTimeoutFloor
+
+	// история задержек: хост отвечает за миллисекунды, и "auto" прижимает таймаут к autoTimeoutFloor
+	warm := func(srv string) {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      makeURLs(t, srv+"/fast", 3*autoTimeoutMinSamples),
+			Workers:   4,
+			TimeoutMS: 5000,
+		})
+
+		for _, r := range got {
+			require.Equal(t, http.StatusOK, r.StatusCode)
+		}
+	}
+
+	srv := newLatencyServer(t, slow)
+	warm(srv.URL)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/slow/1", srv.URL + "/slow/2", srv.URL + "/fast/after"},
+		Workers:   1,
+		TimeoutMS: 5000,
+		Timeout:   autoTimeout,
+	})
+
+	require.Len(t, got, 3)
+	require.Equal(t, errorCodeTimeout, got[0].ErrorCode)
+	require.Equal(t, errorCodeTimeout, got[1].ErrorCode)
+	require.Equal(t, errorCodeHostQuarantined, got[2].ErrorCode)
+	require.Equal(t, circuitOpenError, got[2].Error)
+
+	// исчерпание общего timeout_ms ошибкой хоста не считается
+	other := newLatencyServer(t, slow)
+	warm(other.URL)
+
+	for i := range 2 {
+		got = crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{other.URL + "/slow/" + strconv.Itoa(i)},
+			Workers:   1,
+			TimeoutMS: int(autoTimeoutFloor.Milliseconds()),
+		})
+
+		require.Len(t, got, 1)
+		require.Equal(t, errorCodeTimeout, got[0].ErrorCode)
+	}
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{other.URL + "/fast/after"},
+		Workers:   1,
+		TimeoutMS: 5000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	t.Setenv(breakerThresholdEnv, "1")
+	t.Setenv(breakerCooldownEnv, "10000")
+
+	const cooldown = 10 * time.Second
+
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var (
+		alive atomic.Bool
+		hits  atomic.Int64
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		if !alive.Load() {
+			hj, ok := w.(http.Hijacker)
+			if !ok {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			conn, _, err := hj.Hijack()
+			if err == nil {
+				conn.Close()
+			}
+
+			return
+		}
+
+		// медленный ответ, чтобы остальные урлы пришли, пока проба ещё идёт
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	next := 0
+	batch := func(n int) []CrawlResponse {
+		urls := make([]string, n)
+		for i := range urls {
+			next++
+			urls[i] = srv.URL + "/" + strconv.Itoa(next)
+		}
+
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      urls,
+			Workers:   n,
+			TimeoutMS: 2000,
+		})
+
+		require.Len(t, got, n)
+		return got
+	}
+
+	countCodes := func(got []CrawlResponse) map[string]int {
+		codes := make(map[string]int)
+		for _, r := range got {
+			codes[r.ErrorCode]++
+		}
+
+		return codes
+	}
+
+	require.Equal(t, map[string]int{errorCodeFetchFailed: 1}, countCodes(batch(1)))
+	require.Len(t, getQuarantine(t, c, baseUrl), 1)
+
+	// неудачная проба сразу возвращает хост в карантин
+	clk.Advance(cooldown + time.Second)
+	hits.Store(0)
+
+	require.Equal(t, map[string]int{errorCodeFetchFailed: 1, errorCodeHostQuarantined: 3}, countCodes(batch(4)))
+	require.EqualValues(t, 1, hits.Load())
+	require.Len(t, getQuarantine(t, c, baseUrl), 1)
+
+	// удачная проба закрывает breaker
+	clk.Advance(cooldown + time.Second)
+	alive.Store(true)
+	hits.Store(0)
+
+	require.Equal(t, map[string]int{"": 1, errorCodeHostQuarantined: 3}, countCodes(batch(4)))
+	require.EqualValues(t, 1, hits.Load())
+	require.Empty(t, getQuarantine(t, c, baseUrl))
+
+	require.Equal(t, map[string]int{"": 4}, countCodes(batch(4)))
+
+	m := scrapeMetrics(t, c, baseUrl)
+	require.InDelta(t, 0, m["crawler_circuit_open_hosts"], 0)
+	require.InDelta(t, 2, m["crawler_circuit_opened_total"], 0)
+}
+
+func TestCircuitBreakerConfig(t *testing.T) {
+	for _, tc := range []struct{ env, value, valid string }{
+		{breakerThresholdEnv, "0", "5"},
+		{breakerThresholdEnv, "many", "5"},
+		{breakerCooldownEnv, "-1", "30000"},
+	} {
+		t.Setenv(tc.env, tc.value)
+
+		requireStartError(t, New(), tc)
+
+		t.Setenv(tc.env, tc.valid)
+	}
+}
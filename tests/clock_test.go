@@ -0,0 +1,76 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawlTimeoutFakeClock(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(func() {
+		close(release)
+		srv.Close()
+	})
+
+	const (
+		n       = 3
+		timeout = time.Hour
+	)
+
+	urls := makeURLs(t, srv.URL, n)
+
+	start := time.Now()
+
+	done := crawlAsync(c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   n,
+		TimeoutMS: int(timeout.Milliseconds()),
+	})
+
+	require.Eventually(t, func() bool {
+		return clk.Waiters() > 0
+	}, time.Second, 10*time.Millisecond, "timeout_ms must be scheduled on the injected clock")
+
+	clk.Advance(timeout - time.Millisecond)
+
+	select {
+	case <-done:
+		t.Fatal("crawl finished before timeout elapsed on the fake clock")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	clk.Advance(time.Millisecond)
+
+	got := awaitCrawl(t, done)
+
+	require.Less(t, time.Since(start), 3*time.Second, "crawl did not finish after timeout elapsed on the fake clock")
+	require.Len(t, got, n)
+
+	for i := range urls {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Contains(t, got[i].Error, "timeout exceeded")
+		require.Zero(t, got[i].StatusCode)
+	}
+}
@@ -0,0 +1,196 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type expectedURL struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code,omitempty"`
+}
+
+type compareRequest struct {
+	Expected []expectedURL `json:"expected,omitempty"`
+	Sitemap  string        `json:"sitemap,omitempty"`
+}
+
+type compareDrift struct {
+	URL                string `json:"url"`
+	ExpectedStatusCode int    `json:"expected_status_code"`
+	StatusCode         int    `json:"status_code"`
+}
+
+type compareResponse struct {
+	Missing    []string       `json:"missing"`
+	Unexpected []string       `json:"unexpected"`
+	Drift      []compareDrift `json:"drift"`
+}
+
+func postCompare(t *testing.T, c *http.Client, baseURL *url.URL, id string, body compareRequest) *http.Response {
+	t.Helper()
+
+	reqBody, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	resp, err := c.Post(constructJobsPath(t, baseURL, id, "compare").String(), contentTypeJson, bytes.NewReader(reqBody))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	return resp
+}
+
+func newInventoryServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestJobCompare(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newInventoryServer(t)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/a", srv.URL + "/b", srv.URL + "/x/../c"},
+		Workers:   3,
+		TimeoutMS: 2000,
+	})
+
+	waitJob(t, c, baseUrl, id)
+
+	resp := postCompare(t, c, baseUrl, id, compareRequest{
+		Expected: []expectedURL{
+			{URL: srv.URL + "/a", StatusCode: http.StatusOK},
+			{URL: srv.URL + "/b", StatusCode: http.StatusOK},
+			{URL: srv.URL + "/d"},
+		},
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got compareResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	require.Equal(t, []string{srv.URL + "/d"}, got.Missing)
+	require.Equal(t, []string{srv.URL + "/c"}, got.Unexpected)
+	require.Equal(t, []compareDrift{
+		{URL: srv.URL + "/b", ExpectedStatusCode: http.StatusOK, StatusCode: http.StatusNotFound},
+	}, got.Drift)
+}
+
+func TestJobCompareSitemap(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newInventoryServer(t)
+
+	sitemap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>%[1]s/a</loc></url>
+	<url><loc>%[1]s/b</loc></url>
+	<url><loc>%[1]s/c</loc></url>
+</urlset>`, srv.URL)
+	}))
+
+	t.Cleanup(sitemap.Close)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/a", srv.URL + "/c"},
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	waitJob(t, c, baseUrl, id)
+
+	resp := postCompare(t, c, baseUrl, id, compareRequest{
+		Sitemap: sitemap.URL,
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got compareResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	require.Equal(t, []string{srv.URL + "/b"}, got.Missing)
+	require.NotNil(t, got.Unexpected)
+	require.Empty(t, got.Unexpected)
+	require.NotNil(t, got.Drift)
+	require.Empty(t, got.Drift)
+}
+
+func TestJobCompareNotFinished(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	resp := postCompare(t, c, baseUrl, id, compareRequest{
+		Expected: []expectedURL{{URL: srv.URL}},
+	})
+
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	waitJob(t, c, baseUrl, id)
+}
+
+func TestJobCompareUnknownJob(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	resp := postCompare(t, c, baseUrl, "unknown", compareRequest{
+		Expected: []expectedURL{{URL: "http://example.com"}},
+	})
+
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
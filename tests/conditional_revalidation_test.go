@@ -0,0 +1,180 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const lastModified = "Wed, 01 Jan 2025 00:00:00 GMT"
+
+type conditionalRequest struct {
+	ifNoneMatch, ifModifiedSince string
+}
+
+// newValidatorServer отдаёт ресурс с ETag текущей версии и отвечает 304 на совпадающий If-None-Match
+func newValidatorServer(t *testing.T, withValidators bool) (srv *httptest.Server, setVersion func(string), requests func() []conditionalRequest) {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		version = "v1"
+		seen    []conditionalRequest
+	)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		seen = append(seen, conditionalRequest{
+			ifNoneMatch:     r.Header.Get("If-None-Match"),
+			ifModifiedSince: r.Header.Get("If-Modified-Since"),
+		})
+
+		if !withValidators {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		etag := `"` + version + `"`
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("payload " + version))
+	}))
+
+	t.Cleanup(srv.Close)
+
+	setVersion = func(v string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		version = v
+	}
+
+	requests = func() []conditionalRequest {
+		mu.Lock()
+		defer mu.Unlock()
+
+		out := seen
+		seen = nil
+
+		return out
+	}
+
+	return srv, setVersion, requests
+}
+
+func TestConditionalRevalidation(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, setVersion, requests := newValidatorServer(t, true)
+
+	crawlOne := func() CrawlResponse {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{srv.URL + "/big"},
+			Workers:   1,
+			TimeoutMS: 2000,
+		})
+
+		require.Len(t, got, 1)
+		require.Empty(t, got[0].Error)
+
+		return got[0]
+	}
+
+	r := crawlOne()
+	require.Equal(t, http.StatusOK, r.StatusCode)
+	require.False(t, r.Revalidated)
+	require.Equal(t, []conditionalRequest{{}}, requests())
+
+	// истечение и фоновый проход не выбрасывают запись с валидаторами
+	clk.Advance(cacheTTL + 100*time.Millisecond)
+	clk.Advance(cacheTTL)
+
+	r = crawlOne()
+	require.Equal(t, http.StatusOK, r.StatusCode)
+	require.True(t, r.Revalidated)
+	require.Equal(t, []conditionalRequest{{ifNoneMatch: `"v1"`, ifModifiedSince: lastModified}}, requests())
+
+	// продлённая запись снова отдаётся из кэша
+	r = crawlOne()
+	require.Equal(t, http.StatusOK, r.StatusCode)
+	require.Empty(t, requests())
+
+	setVersion("v2")
+	clk.Advance(cacheTTL + 100*time.Millisecond)
+
+	r = crawlOne()
+	require.Equal(t, http.StatusOK, r.StatusCode)
+	require.False(t, r.Revalidated)
+	require.Equal(t, []conditionalRequest{{ifNoneMatch: `"v1"`, ifModifiedSince: lastModified}}, requests())
+
+	clk.Advance(cacheTTL + 100*time.Millisecond)
+
+	r = crawlOne()
+	require.True(t, r.Revalidated)
+	require.Equal(t, []conditionalRequest{{ifNoneMatch: `"v2"`, ifModifiedSince: lastModified}}, requests())
+
+	m := scrapeMetrics(t, c, baseUrl)
+	require.InDelta(t, 2, m[`crawler_cache_revalidations_total{result="not_modified"}`], 0)
+	require.InDelta(t, 1, m[`crawler_cache_revalidations_total{result="modified"}`], 0)
+}
+
+func TestConditionalRevalidationRetention(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	withValidators, _, withRequests := newValidatorServer(t, true)
+	plain, _, plainRequests := newValidatorServer(t, false)
+
+	req := CrawlRequest{
+		URLs:      []string{withValidators.URL + "/a", plain.URL + "/b"},
+		Workers:   2,
+		TimeoutMS: 2000,
+	}
+
+	got := crawl(t, c, baseUrl, req)
+	require.Len(t, got, 2)
+	require.Len(t, withRequests(), 1)
+	require.Len(t, plainRequests(), 1)
+
+	clk.Advance(cacheTTL + 100*time.Millisecond)
+
+	got = crawl(t, c, baseUrl, req)
+	require.Len(t, got, 2)
+	require.True(t, got[0].Revalidated)
+	require.False(t, got[1].Revalidated)
+
+	// без валидаторов условный запрос не отправляется
+	require.Equal(t, []conditionalRequest{{}}, plainRequests())
+	require.Len(t, withRequests(), 1)
+
+	// после validatorRetention без продлений запись удаляется, и загрузка снова безусловная
+	for elapsed := time.Duration(0); elapsed <= validatorRetention; elapsed += cacheTTL {
+		clk.Advance(cacheTTL)
+	}
+
+	got = crawl(t, c, baseUrl, req)
+	require.Len(t, got, 2)
+	require.False(t, got[0].Revalidated)
+	require.Equal(t, []conditionalRequest{{}}, withRequests())
+}
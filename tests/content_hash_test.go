@@ -0,0 +1,111 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newContentServer отдаёт текущее содержимое, которое тест может менять
+func newContentServer(t *testing.T) (srv *httptest.Server, set func(string)) {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		content = "v1"
+	)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		_, _ = w.Write([]byte(content))
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv, func(c string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		content = c
+	}
+}
+
+func TestCrawlContentHash(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, setContent := newContentServer(t)
+	target := srv.URL + "/page"
+
+	crawlOne := func(req CrawlRequest) CrawlResponse {
+		req.URLs = []string{target}
+		req.Workers = 1
+		req.TimeoutMS = 2000
+
+		got := crawl(t, c, baseUrl, req)
+		require.Len(t, got, 1)
+		require.Empty(t, got[0].Error)
+
+		return got[0]
+	}
+
+	beforeAll := clk.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	r := crawlOne(CrawlRequest{HashBody: true})
+	require.Equal(t, sha256Hex("v1"), r.ContentSHA256)
+	require.Nil(t, r.ContentChanged)
+
+	// из кэша - тот же хэш
+	r = crawlOne(CrawlRequest{HashBody: true})
+	require.Equal(t, sha256Hex("v1"), r.ContentSHA256)
+
+	clk.Advance(time.Hour)
+	sawV1 := clk.Now().Format(time.RFC3339)
+
+	setContent("v2")
+	clk.Advance(cacheTTL + time.Second)
+
+	r = crawlOne(CrawlRequest{ChangedSince: sawV1})
+	require.Equal(t, sha256Hex("v2"), r.ContentSHA256)
+	require.NotNil(t, r.ContentChanged)
+	require.True(t, *r.ContentChanged)
+
+	// в тот момент урл ещё не наблюдался
+	r = crawlOne(CrawlRequest{ChangedSince: beforeAll})
+	require.Nil(t, r.ContentChanged)
+
+	clk.Advance(time.Second)
+	r = crawlOne(CrawlRequest{ChangedSince: clk.Now().Format(time.RFC3339)})
+	require.NotNil(t, r.ContentChanged)
+	require.False(t, *r.ContentChanged)
+
+	// без hash_body хэша нет
+	r = crawlOne(CrawlRequest{})
+	require.Empty(t, r.ContentSHA256)
+}
+
+func TestCrawlContentHashValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, req := range []CrawlRequest{
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, HashBody: true, Mode: "head"},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, ChangedSince: "2025-12-01T10:00:00Z", Mode: "head"},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, ChangedSince: "yesterday"},
+	} {
+		resp := postCrawl(t, c, baseUrl, req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+}
@@ -0,0 +1,238 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDefaultPorts(t *testing.T) {
+	cases := []struct {
+		a, b string
+		same bool
+	}{
+		{a: "http://example.com:80/a", b: "http://example.com/a", same: true},
+		{a: "https://example.com:443/a", b: "https://example.com/a", same: true},
+		{a: "ftp://example.com:21/pub/file", b: "ftp://example.com/pub/file", same: true},
+		{a: "http://example.com:/a", b: "http://example.com/a", same: true},
+		{a: "HTTP://Example.COM:80/a", b: "http://example.com/a", same: true},
+		{a: "https://example.com:80/a", b: "https://example.com/a", same: false},
+		{a: "http://example.com:443/a", b: "http://example.com/a", same: false},
+		{a: "http://example.com:8080/a", b: "http://example.com/a", same: false},
+	}
+
+	for _, mode := range []string{"standard", "strict"} {
+		for _, tc := range cases {
+			a, err := normalizeURLWith(tc.a, mode)
+			require.NoError(t, err)
+
+			b, err := normalizeURLWith(tc.b, mode)
+			require.NoError(t, err)
+
+			if tc.same {
+				require.Equal(t, b, a, "%s: %q vs %q", mode, tc.a, tc.b)
+			} else {
+				require.NotEqual(t, b, a, "%s: %q vs %q", mode, tc.a, tc.b)
+			}
+
+			again, err := normalizeURLWith(a, mode)
+			require.NoError(t, err)
+			require.Equal(t, a, again, "%s is not idempotent for %q", mode, tc.a)
+		}
+	}
+
+	off, err := normalizeURLWith("http://example.com:80/a", "off")
+	require.NoError(t, err)
+
+	bare, err := normalizeURLWith("http://example.com/a", "off")
+	require.NoError(t, err)
+	require.NotEqual(t, bare, off)
+}
+
+func TestDefaultPortSharesHostKey(t *testing.T) {
+	// нужен закрытый порт 80 на localhost, чтобы запросы падали с fetch_failed
+	if conn, err := net.DialTimeout("tcp", "127.0.0.1:80", 200*time.Millisecond); err == nil {
+		conn.Close()
+		t.Skip("port 80 is in use")
+	}
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	urls := make([]string, 0, quarantineErrorThreshold)
+	for i := range quarantineErrorThreshold {
+		if i%2 == 0 {
+			urls = append(urls, "http://127.0.0.1:80/explicit-"+strconv.Itoa(i))
+		} else {
+			urls = append(urls, "http://127.0.0.1/implicit-"+strconv.Itoa(i))
+		}
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   1,
+		TimeoutMS: 5000,
+	})
+
+	require.Len(t, got, len(urls))
+	for _, r := range got {
+		require.Equal(t, errorCodeFetchFailed, r.ErrorCode, r.URL)
+	}
+
+	// ошибки обоих написаний засчитаны одному хосту
+	entries := getQuarantine(t, c, baseUrl)
+	require.Len(t, entries, 1)
+	require.Equal(t, "127.0.0.1:80", entries[0].Host)
+	require.Equal(t, quarantineErrorThreshold, entries[0].Errors)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://127.0.0.1/next", "http://127.0.0.1:80/next-explicit"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 2)
+	for _, r := range got {
+		require.Equal(t, errorCodeHostQuarantined, r.ErrorCode, r.URL)
+	}
+
+	require.Equal(t, http.StatusNoContent, liftQuarantine(t, c, baseUrl, "127.0.0.1:80"))
+}
+
+// redirectHostTransport отправляет все запросы обхода на target, сохраняя исходный урл в заголовке Host
+type redirectHostTransport struct {
+	target string
+}
+
+func (rt *redirectHostTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Host = r.URL.Host
+	r.URL.Host = rt.target
+
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// newPortsServer считает запросы и пик одновременных запросов; каждый запрос длится delay
+func newPortsServer(t *testing.T, delay time.Duration) (srv *httptest.Server, hits func() int, peak func() int) {
+	t.Helper()
+
+	var (
+		mu                   sync.Mutex
+		total, current, high int
+	)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		total++
+		current++
+		high = max(high, current)
+		mu.Unlock()
+
+		time.Sleep(delay)
+
+		mu.Lock()
+		current--
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	locked := func(v *int) func() int {
+		return func() int {
+			mu.Lock()
+			defer mu.Unlock()
+
+			return *v
+		}
+	}
+
+	return srv, locked(&total), locked(&high)
+}
+
+func TestDefaultPortSharesCache(t *testing.T) {
+	srv, hits, _ := newPortsServer(t, 50*time.Millisecond)
+
+	baseUrl, stopWait := serveCrawler(t.Context(), t, New(WithTransport(&redirectHostTransport{target: srv.Listener.Addr().String()})))
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	// дедупликация и singleflight внутри одного обхода
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://example.test/page", "http://example.test:80/page", "http://EXAMPLE.test:/page"},
+		Workers:   3,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 3)
+	for _, r := range got {
+		require.Equal(t, http.StatusOK, r.StatusCode, r.URL)
+	}
+
+	require.Equal(t, 1, hits())
+
+	// кэш ответов между обходами
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://example.test:80/page"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Equal(t, 1, hits())
+
+	// другой порт - другой урл
+	crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://example.test:8080/page"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Equal(t, 2, hits())
+}
+
+func TestDefaultPortSharesHostLimit(t *testing.T) {
+	srv, hits, peak := newPortsServer(t, 50*time.Millisecond)
+
+	baseUrl, stopWait := serveCrawler(t.Context(), t, New(WithTransport(&redirectHostTransport{target: srv.Listener.Addr().String()})))
+	t.Cleanup(stopWait)
+
+	const n = 8
+
+	urls := make([]string, 0, n)
+	for i := range n {
+		if i%2 == 0 {
+			urls = append(urls, "http://example.test:80/explicit-"+strconv.Itoa(i))
+		} else {
+			urls = append(urls, "http://example.test/implicit-"+strconv.Itoa(i))
+		}
+	}
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:                 urls,
+		Workers:              n,
+		TimeoutMS:            5000,
+		MaxConcurrentPerHost: 1,
+	})
+
+	require.Len(t, got, n)
+	for _, r := range got {
+		require.Equal(t, http.StatusOK, r.StatusCode, r.URL)
+	}
+
+	require.Equal(t, n, hits())
+	require.Equal(t, 1, peak(), "both spellings must share one max_concurrent_per_host slot")
+}
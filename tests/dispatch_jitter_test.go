@@ -0,0 +1,86 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatchJitter(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var (
+		mu      sync.Mutex
+		arrived []time.Time
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		arrived = append(arrived, time.Now())
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	const (
+		n      = 20
+		jitter = 400 * time.Millisecond
+	)
+
+	urls := makeURLs(t, srv.URL, n)
+
+	start := time.Now()
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:             urls,
+		Workers:          n,
+		TimeoutMS:        3000,
+		DispatchJitterMS: int(jitter.Milliseconds()),
+	})
+
+	require.Len(t, got, n)
+	for i := range urls {
+		require.Equal(t, http.StatusOK, got[i].StatusCode)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, arrived, n)
+
+	first := slices.MinFunc(arrived, func(a, b time.Time) int { return a.Compare(b) })
+	last := slices.MaxFunc(arrived, func(a, b time.Time) int { return a.Compare(b) })
+
+	// при равномерном разбросе 20 запросов почти наверняка растянуты больше чем на четверть окна
+	require.Greater(t, last.Sub(first), jitter/4)
+	require.Less(t, last.Sub(start), jitter+time.Second)
+}
+
+func TestDispatchJitterValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, jitter := range []int{-1, 1001} {
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{
+			URLs:             []string{"http://127.0.0.1/"},
+			Workers:          1,
+			TimeoutMS:        1000,
+			DispatchJitterMS: jitter,
+		})
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, jitter)
+	}
+}
@@ -0,0 +1,151 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResolver struct {
+	addrs   map[string][]net.IPAddr
+	lookups atomic.Int64
+}
+
+func (r *fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	r.lookups.Add(1)
+
+	addrs, ok := r.addrs[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	return addrs, nil
+}
+
+// setDNSResolver подменяет резолвер. Вызывать до старта краулера.
+func setDNSResolver(t *testing.T, r hostResolver) {
+	t.Helper()
+
+	prev := dnsResolver
+	dnsResolver = r
+
+	t.Cleanup(func() {
+		dnsResolver = prev
+	})
+}
+
+func TestDNSCacheAndRemoteAddr(t *testing.T) {
+	resolver := &fakeResolver{addrs: map[string][]net.IPAddr{
+		"site.test": {{IP: net.ParseIP("127.0.0.1")}, {IP: net.ParseIP("::1")}},
+	}}
+
+	setDNSResolver(t, resolver)
+
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	_, port, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+
+	named := "http://" + net.JoinHostPort("site.test", port)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{named + "/a", srv.URL + "/literal"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 2)
+
+	require.Empty(t, got[0].Error)
+	require.Equal(t, u.Host, got[0].RemoteAddr)
+	require.Equal(t, []string{"127.0.0.1", "::1"}, got[0].ResolvedAddrs)
+	require.False(t, got[0].DNSCached)
+
+	require.Equal(t, u.Host, got[1].RemoteAddr)
+	require.Empty(t, got[1].ResolvedAddrs)
+	require.False(t, got[1].DNSCached)
+
+	require.EqualValues(t, 1, resolver.lookups.Load())
+
+	// второй урл того же хоста берёт адреса из кэша
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:              []string{named + "/b"},
+		Workers:           1,
+		TimeoutMS:         2000,
+		IsolatedTransport: true,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, u.Host, got[0].RemoteAddr)
+	require.Equal(t, []string{"127.0.0.1", "::1"}, got[0].ResolvedAddrs)
+	require.True(t, got[0].DNSCached)
+	require.EqualValues(t, 1, resolver.lookups.Load())
+
+	// результат из кэша ответов сохраняет адреса
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{named + "/a"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, u.Host, got[0].RemoteAddr)
+	require.False(t, got[0].DNSCached)
+
+	clk.Advance(dnsCacheTTL + time.Second)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:              []string{named + "/c"},
+		Workers:           1,
+		TimeoutMS:         2000,
+		IsolatedTransport: true,
+	})
+
+	require.Len(t, got, 1)
+	require.False(t, got[0].DNSCached)
+	require.EqualValues(t, 2, resolver.lookups.Load())
+
+	m := scrapeMetrics(t, c, baseUrl)
+	require.InDelta(t, 2, m["crawler_dns_lookups_total"], 0)
+	require.InDelta(t, 1, m["crawler_dns_cache_hits_total"], 0)
+}
+
+func TestDNSErrorsNotCached(t *testing.T) {
+	resolver := &fakeResolver{addrs: map[string][]net.IPAddr{}}
+	setDNSResolver(t, resolver)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for i := range 2 {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{"http://missing.test/" + strconv.Itoa(i)},
+			Workers:   1,
+			TimeoutMS: 2000,
+		})
+
+		require.Len(t, got, 1)
+		require.Equal(t, errorCodeFetchFailed, got[0].ErrorCode)
+		require.Empty(t, got[0].RemoteAddr)
+	}
+
+	require.EqualValues(t, 2, resolver.lookups.Load())
+}
@@ -0,0 +1,293 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const drainPath = "/admin/drain"
+
+type drainStatus struct {
+	Draining bool `json:"draining"`
+	InFlight int  `json:"in_flight"`
+}
+
+// drainRequest не вызывает require, поэтому годится и для условий require.Eventually
+func drainRequest(c *http.Client, baseURL *url.URL, method, key string) (int, drainStatus, error) {
+	req, err := http.NewRequest(method, baseURL.JoinPath(drainPath).String(), nil)
+	if err != nil {
+		return 0, drainStatus{}, err
+	}
+
+	if key != "" {
+		req.Header.Set(apiKeyHeader, key)
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, drainStatus{}, err
+	}
+	defer resp.Body.Close()
+
+	var st drainStatus
+	if resp.StatusCode == http.StatusOK {
+		err = json.NewDecoder(resp.Body).Decode(&st)
+	}
+
+	return resp.StatusCode, st, err
+}
+
+func callDrain(t *testing.T, c *http.Client, baseURL *url.URL, method string) (int, drainStatus) {
+	t.Helper()
+
+	code, st, err := drainRequest(c, baseURL, method, "")
+	require.NoError(t, err)
+
+	return code, st
+}
+
+// drainIdle - условие для require.Eventually: сервер в drain и незавершённых запросов нет
+func drainIdle(c *http.Client, baseURL *url.URL) func() bool {
+	return func() bool {
+		code, st, err := drainRequest(c, baseURL, http.MethodGet, "")
+		return err == nil && code == http.StatusOK && st.InFlight == 0
+	}
+}
+
+func TestDrain(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, inFlight, release := newGateServer(t)
+
+	urls := makeURLs(t, srv.URL+"/drain", 2)
+
+	result := crawlAsync(c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 5000,
+	})
+
+	require.Eventually(t, func() bool {
+		return inFlight("drain") == 2
+	}, time.Second, 10*time.Millisecond)
+
+	code, st := callDrain(t, c, baseUrl, http.MethodPost)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, drainStatus{Draining: true, InFlight: 1}, st)
+
+	// новые обходы не принимаются
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/late"},
+		Workers:   1,
+		TimeoutMS: 1000,
+	})
+
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	late := CrawlRequest{URLs: []string{srv.URL + "/late"}, Workers: 1, TimeoutMS: 1000}
+
+	for _, u := range []*url.URL{constructJobsPath(t, baseUrl), baseUrl.JoinPath(crawlPath, asyncPath)} {
+		resp := postWithKey(t, c, u, "", late)
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, u.Path)
+	}
+
+	// начатый обход завершается как обычно
+	release()
+
+	got := awaitCrawl(t, result)
+	require.Len(t, got, len(urls))
+
+	for i := range urls {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Equal(t, http.StatusNoContent, got[i].StatusCode)
+	}
+
+	require.Eventually(t, drainIdle(c, baseUrl), time.Second, 10*time.Millisecond)
+
+	code, st = callDrain(t, c, baseUrl, http.MethodGet)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, drainStatus{Draining: true, InFlight: 0}, st)
+
+	code, _ = callDrain(t, c, baseUrl, http.MethodDelete)
+	require.Equal(t, http.StatusNoContent, code)
+
+	code, st = callDrain(t, c, baseUrl, http.MethodGet)
+	require.Equal(t, http.StatusOK, code)
+	require.False(t, st.Draining)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/after"},
+		Workers:   1,
+		TimeoutMS: 1000,
+	})
+
+	require.Len(t, got, 1)
+}
+
+func TestDrainCountsJobs(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, inFlight, release := newGateServer(t)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/job/1"},
+		Workers:   1,
+		TimeoutMS: 5000,
+	})
+
+	require.Eventually(t, func() bool {
+		return inFlight("job") == 1
+	}, time.Second, 10*time.Millisecond)
+
+	code, st := callDrain(t, c, baseUrl, http.MethodPost)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, drainStatus{Draining: true, InFlight: 1}, st)
+
+	release()
+
+	// задача дорабатывает, её события по-прежнему читаются
+	events := waitJob(t, c, baseUrl, id)
+	require.Equal(t, "done", events[len(events)-1].Name)
+
+	require.Eventually(t, drainIdle(c, baseUrl), time.Second, 10*time.Millisecond)
+}
+
+func TestDrainTemplateRun(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	require.Equal(t, http.StatusCreated, templateRequest(t, c, http.MethodPut, baseUrl.JoinPath(templatesPath, "nightly"), CrawlRequest{
+		URLs:      []string{srv.URL + "/200"},
+		Workers:   1,
+		TimeoutMS: 1000,
+	}).StatusCode)
+
+	run := baseUrl.JoinPath(templatesPath, "nightly", "run")
+
+	code, _ := callDrain(t, c, baseUrl, http.MethodPost)
+	require.Equal(t, http.StatusOK, code)
+
+	resp := templateRequest(t, c, http.MethodPost, run, nil)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	// шаблоны по-прежнему читаются
+	require.Equal(t, http.StatusOK, templateRequest(t, c, http.MethodGet, baseUrl.JoinPath(templatesPath, "nightly"), nil).StatusCode)
+
+	code, _ = callDrain(t, c, baseUrl, http.MethodDelete)
+	require.Equal(t, http.StatusNoContent, code)
+
+	require.Equal(t, http.StatusAccepted, templateRequest(t, c, http.MethodPost, run, nil).StatusCode)
+}
+
+func TestDrainRequiresAdmin(t *testing.T) {
+	setAPIKeyRoles(t, map[string]string{
+		"r": roleReader,
+		"s": roleSubmitter,
+		"a": roleAdmin,
+	})
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodDelete} {
+		for key, want := range map[string]int{
+			"":        http.StatusUnauthorized,
+			"unknown": http.StatusUnauthorized,
+			"r":       http.StatusForbidden,
+			"s":       http.StatusForbidden,
+		} {
+			code, _, err := drainRequest(c, baseUrl, method, key)
+			require.NoError(t, err)
+			require.Equal(t, want, code, "%s with key %q", method, key)
+		}
+	}
+
+	// отказ не меняет режим
+	code, st, err := drainRequest(c, baseUrl, http.MethodGet, "a")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+	require.False(t, st.Draining)
+
+	code, st, err = drainRequest(c, baseUrl, http.MethodPost, "a")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, st.Draining)
+
+	code, _, err = drainRequest(c, baseUrl, http.MethodDelete, "a")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, code)
+}
+
+func TestShutdownTimeoutOption(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	port := findFreePort(t)
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- New(WithShutdownTimeout(timeout)).ListenAndServe(ctx, port)
+	}()
+
+	baseUrl, err := url.Parse("http://127.0.0.1" + port)
+	require.NoError(t, err)
+	require.True(t, waitHTTPUp(t, baseUrl, serverUpTTL))
+
+	c := client()
+	srv, inFlight, release := newGateServer(t)
+	t.Cleanup(release)
+
+	reqBody, err := json.Marshal(CrawlRequest{
+		URLs:      []string{srv.URL + "/stuck/1"},
+		Workers:   1,
+		TimeoutMS: 60_000,
+	})
+	require.NoError(t, err)
+
+	crawlURL := constructCrawlPath(t, baseUrl).String()
+
+	go func() {
+		// соединение будет закрыто принудительно, ошибка ожидаема
+		resp, err := c.Post(crawlURL, contentTypeJson, bytes.NewReader(reqBody))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	require.Eventually(t, func() bool {
+		return inFlight("stuck") == 1
+	}, time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+	cancel()
+
+	select {
+	case err := <-errCh:
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), timeout+time.Second)
+	case <-time.After(serverDownTTL):
+		t.Fatal("server did not stop in time")
+	}
+}
@@ -0,0 +1,122 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	defaultWorkersEnv      = "CRAWLER_DEFAULT_WORKERS"
+	effectiveWorkersHeader = "X-Effective-Workers"
+)
+
+type workersSummary struct {
+	Requested int `json:"requested"`
+	Effective int `json:"effective"`
+}
+
+func TestWorkersClampedToUniqueURLs(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, inFlight, release := newGateServer(t)
+
+	urls := append(makeURLs(t, srv.URL+"/clamp", 2), srv.URL+"/clamp/item-0", srv.URL+"/clamp/item-1/")
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   16,
+		TimeoutMS: 5000,
+	})
+
+	require.Eventually(t, func() bool {
+		return inFlight("clamp") == 2
+	}, time.Second, 20*time.Millisecond)
+
+	report := getLeaks(t, c, baseUrl)
+	require.Equal(t, 2, report.Workers)
+
+	release()
+
+	events := waitJob(t, c, baseUrl, id)
+	require.NotEmpty(t, events)
+
+	var done struct {
+		Workers workersSummary `json:"workers"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(events[len(events)-1].Data), &done))
+	require.Equal(t, workersSummary{Requested: 16, Effective: 2}, done.Workers)
+
+	srvStatus := newStatusServer(t)
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srvStatus.URL + "/200", srvStatus.URL + "/204", srvStatus.URL + "/200"},
+		Workers:   10,
+		TimeoutMS: 2000,
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "2", resp.Header.Get(effectiveWorkersHeader))
+
+	resp = postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{},
+		Workers:   10,
+		TimeoutMS: 2000,
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get(effectiveWorkersHeader))
+}
+
+func TestDefaultWorkers(t *testing.T) {
+	t.Setenv(defaultWorkersEnv, "3")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL, 10),
+		TimeoutMS: 2000,
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "3", resp.Header.Get(effectiveWorkersHeader))
+
+	var got []CrawlResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Len(t, got, 10)
+
+	resp = postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/204"},
+		TimeoutMS: 2000,
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get(effectiveWorkersHeader))
+}
+
+func TestZeroWorkersWithoutDefault(t *testing.T) {
+	t.Setenv(defaultWorkersEnv, "")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://127.0.0.1/"},
+		TimeoutMS: 1000,
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,217 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	fileRootsEnv               = "CRAWLER_FILE_ROOTS"
+	errorCodeFileNotAllowed    = "file_not_allowed"
+	errorCodeUnsupportedScheme = "unsupported_scheme"
+)
+
+// startFakeFTP отвечает на минимальный набор команд: USER, PASS, TYPE, SIZE, QUIT
+func startFakeFTP(t *testing.T, password string, files map[string]int) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(format string, args ...any) {
+			fmt.Fprintf(conn, format+"\r\n", args...)
+		}
+
+		reply("220 fake ftp ready")
+
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			cmd, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+
+			switch strings.ToUpper(cmd) {
+			case "USER":
+				reply("331 password required")
+			case "PASS":
+				if arg != password {
+					reply("530 login incorrect")
+					continue
+				}
+
+				reply("230 logged in")
+			case "TYPE":
+				reply("200 type set")
+			case "SIZE":
+				code, ok := files[arg]
+				switch {
+				case !ok:
+					reply("550 no such file")
+				case code != 0:
+					reply("%d unavailable", code)
+				default:
+					reply("213 42")
+				}
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go serve(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestCrawlFTP(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	anonymous := startFakeFTP(t, "anonymous", map[string]int{
+		"/pub/file.tar.gz": 0,
+		"/pub/busy":        450,
+		"/pub/broken":      551,
+	})
+
+	private := startFakeFTP(t, "secret", map[string]int{
+		"/file": 0,
+	})
+
+	expected := []struct {
+		url    string
+		status int
+	}{
+		{url: "ftp://" + anonymous + "/pub/file.tar.gz", status: http.StatusOK},
+		{url: "ftp://" + anonymous + "/pub/missing", status: http.StatusNotFound},
+		{url: "ftp://" + anonymous + "/pub/busy", status: http.StatusServiceUnavailable},
+		{url: "ftp://" + anonymous + "/pub/broken", status: http.StatusBadGateway},
+		{url: "ftp://user:secret@" + private + "/file", status: http.StatusOK},
+		{url: "ftp://user:wrong@" + private + "/file", status: http.StatusUnauthorized},
+	}
+
+	urls := make([]string, 0, len(expected))
+	for _, e := range expected {
+		urls = append(urls, e.url)
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   len(urls),
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, len(urls))
+	for i, e := range expected {
+		require.Equal(t, e.url, got[i].URL)
+		require.Empty(t, got[i].Error)
+		require.Equal(t, e.status, got[i].StatusCode, "url %s", e.url)
+	}
+}
+
+func TestCrawlFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require extra privileges on windows")
+	}
+
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "artifact.txt"), []byte("ok"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o600))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "link.txt")))
+
+	t.Setenv(fileRootsEnv, root)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	fileURL := func(p string) string {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(p)}).String()
+	}
+
+	urls := []string{
+		fileURL(filepath.Join(root, "artifact.txt")),
+		fileURL(filepath.Join(root, "missing.txt")),
+		fileURL(filepath.Join(outside, "secret.txt")),
+		fileURL(root) + "/../" + filepath.Base(outside) + "/secret.txt",
+		fileURL(filepath.Join(root, "link.txt")),
+		"gopher://example.com/",
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   len(urls),
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, len(urls))
+
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Equal(t, http.StatusNotFound, got[1].StatusCode)
+
+	for i := 2; i <= 4; i++ {
+		require.Zero(t, got[i].StatusCode, "url %s", urls[i])
+		require.Equal(t, errorCodeFileNotAllowed, got[i].ErrorCode, "url %s", urls[i])
+	}
+
+	require.Equal(t, errorCodeUnsupportedScheme, got[5].ErrorCode)
+}
+
+func TestCrawlFileWithoutRoots(t *testing.T) {
+	t.Setenv(fileRootsEnv, "")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "artifact.txt"), []byte("ok"), 0o600))
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{(&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(dir, "artifact.txt"))}).String()},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, errorCodeFileNotAllowed, got[0].ErrorCode)
+}
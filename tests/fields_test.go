@@ -0,0 +1,120 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mapKeys(m map[string]any) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+
+	return out
+}
+
+func TestCrawlFields(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL + "/200", srv.URL + "/500"},
+		Workers:   2,
+		TimeoutMS: 2000,
+		Fields:    []string{"url", "status_code"},
+	}
+
+	resp := postCrawl(t, c, baseUrl, req)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got []map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Len(t, got, 2)
+
+	for i, r := range got {
+		require.ElementsMatch(t, []string{"url", "status_code"}, mapKeys(r))
+		require.Equal(t, req.URLs[i], r["url"])
+	}
+
+	// параметр запроса важнее тела
+	target := constructCrawlPath(t, baseUrl)
+	target.RawQuery = url.Values{"fields": {"success"}}.Encode()
+
+	resp = postWithKey(t, c, target, "", req)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// новая переменная: Decode в got переиспользовал бы карты первого ответа вместе с их ключами
+	var overridden []map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&overridden))
+	require.Len(t, overridden, 2)
+
+	require.Equal(t, map[string]any{"success": true}, overridden[0])
+	require.Equal(t, map[string]any{"success": false}, overridden[1])
+}
+
+func TestJobResultsFields(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/200", srv.URL + "/404"},
+		Workers:   2,
+		TimeoutMS: 2000,
+		Fields:    []string{"error_code"},
+	})
+
+	for _, e := range waitJob(t, c, baseUrl, id) {
+		if e.Name != "result" {
+			continue
+		}
+
+		var r map[string]any
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &r))
+		require.Contains(t, r, "index")
+		require.NotContains(t, r, "url")
+		require.NotContains(t, r, "success")
+	}
+
+	resp := getJobResults(t, c, baseUrl, id, url.Values{"fields": {"url,status_code"}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var page struct {
+		Results []map[string]any `json:"results"`
+	}
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+	require.Len(t, page.Results, 2)
+
+	for _, r := range page.Results {
+		require.ElementsMatch(t, []string{"seq", "index", "url", "status_code"}, mapKeys(r))
+	}
+}
+
+func TestFieldsValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://127.0.0.1/"},
+		Workers:   1,
+		TimeoutMS: 1000,
+		Fields:    []string{"url", "StatusCode"},
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,355 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const graphqlPath = "/graphql"
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func postGraphQL(t *testing.T, c *http.Client, baseURL *url.URL, accept, query string, vars map[string]any) *http.Response {
+	t.Helper()
+
+	reqBody, err := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": vars,
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, baseURL.JoinPath(graphqlPath).String(), bytes.NewReader(reqBody))
+	require.NoError(t, err)
+
+	req.Header.Set("Content-Type", contentTypeJson)
+	req.Header.Set("Accept", accept)
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	return resp
+}
+
+func doGraphQL(t *testing.T, c *http.Client, baseURL *url.URL, query string, vars map[string]any) graphqlResponse {
+	t.Helper()
+
+	resp := postGraphQL(t, c, baseURL, contentTypeJson, query, vars)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got graphqlResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	return got
+}
+
+// readGraphQL не вызывает require, поэтому годится и для условий require.Eventually
+func readGraphQL(c *http.Client, baseURL *url.URL, query string, vars map[string]any) (graphqlResponse, error) {
+	var got graphqlResponse
+
+	reqBody, err := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": vars,
+	})
+	if err != nil {
+		return got, err
+	}
+
+	resp, err := c.Post(baseURL.JoinPath(graphqlPath).String(), contentTypeJson, bytes.NewReader(reqBody))
+	if err != nil {
+		return got, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return got, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&got)
+	return got, err
+}
+
+func TestGraphQLCrawlFieldSelection(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	const n = 5
+	urls := makeURLs(t, srv.URL, n)
+
+	got := doGraphQL(t, c, baseUrl, `
+		mutation Crawl($urls: [String!]!) {
+			crawl(urls: $urls, workers: 2, timeoutMs: 2000) {
+				url
+				statusCode
+			}
+		}`, map[string]any{"urls": urls})
+
+	require.Empty(t, got.Errors)
+
+	var data struct {
+		Crawl []map[string]any `json:"crawl"`
+	}
+
+	require.NoError(t, json.Unmarshal(got.Data, &data))
+	require.Len(t, data.Crawl, n)
+
+	for i := range urls {
+		require.Len(t, data.Crawl[i], 2, "only requested fields expected")
+		require.Equal(t, urls[i], data.Crawl[i]["url"])
+		require.EqualValues(t, http.StatusNoContent, data.Crawl[i]["statusCode"])
+	}
+}
+
+func TestGraphQLCrawlValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	got := doGraphQL(t, c, baseUrl, `
+		mutation {
+			crawl(urls: ["http://example.com"], workers: 0, timeoutMs: 1000) {
+				url
+			}
+		}`, nil)
+
+	require.NotEmpty(t, got.Errors)
+	require.JSONEq(t, "null", string(got.Data))
+}
+
+func TestGraphQLJobQuery(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	const n = 4
+	urls := makeURLs(t, srv.URL, n)
+
+	submitted := doGraphQL(t, c, baseUrl, `
+		mutation Submit($urls: [String!]!) {
+			submitJob(urls: $urls, workers: 2, timeoutMs: 2000) {
+				id
+				total
+			}
+		}`, map[string]any{"urls": urls})
+
+	require.Empty(t, submitted.Errors)
+
+	var job struct {
+		SubmitJob struct {
+			ID    string `json:"id"`
+			Total int    `json:"total"`
+		} `json:"submitJob"`
+	}
+
+	require.NoError(t, json.Unmarshal(submitted.Data, &job))
+	require.NotEmpty(t, job.SubmitJob.ID)
+	require.Equal(t, n, job.SubmitJob.Total)
+
+	type jobData struct {
+		Job struct {
+			Done    int `json:"done"`
+			Total   int `json:"total"`
+			Results []struct {
+				Index      int    `json:"index"`
+				URL        string `json:"url"`
+				StatusCode int    `json:"statusCode"`
+			} `json:"results"`
+		} `json:"job"`
+	}
+
+	var data jobData
+
+	require.Eventually(t, func() bool {
+		got, err := readGraphQL(c, baseUrl, `
+			query Job($id: ID!) {
+				job(id: $id) {
+					done
+					total
+					results { index url statusCode }
+				}
+			}`, map[string]any{"id": job.SubmitJob.ID})
+		if err != nil || len(got.Errors) > 0 {
+			return false
+		}
+
+		var polled jobData
+		if json.Unmarshal(got.Data, &polled) != nil {
+			return false
+		}
+
+		data = polled
+		return data.Job.Done == data.Job.Total
+	}, 3*time.Second, 50*time.Millisecond)
+
+	require.Equal(t, n, data.Job.Total)
+	require.Len(t, data.Job.Results, n)
+
+	for _, r := range data.Job.Results {
+		require.Equal(t, urls[r.Index], r.URL)
+		require.Equal(t, http.StatusOK, r.StatusCode)
+	}
+}
+
+func TestGraphQLUnknownJob(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	got := doGraphQL(t, c, baseUrl, `query { job(id: "unknown") { id } }`, nil)
+
+	require.Empty(t, got.Errors)
+	require.JSONEq(t, `{"job": null}`, string(got.Data))
+}
+
+type graphQLProgress struct {
+	Done   int `json:"done"`
+	Total  int `json:"total"`
+	Result struct {
+		URL        string `json:"url"`
+		StatusCode int    `json:"statusCode"`
+	} `json:"result"`
+}
+
+func subscribeProgress(t *testing.T, c *http.Client, baseURL *url.URL, id string) []graphQLProgress {
+	t.Helper()
+
+	resp := postGraphQL(t, c, baseURL, contentTypeEventStream, `
+		subscription Progress($id: ID!) {
+			progress(jobId: $id) {
+				done
+				total
+				result { url statusCode }
+			}
+		}`, map[string]any{"id": id})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Content-Type"), contentTypeEventStream)
+
+	events := readSSE(t, resp.Body)
+	require.NotEmpty(t, events)
+	require.Equal(t, "complete", events[len(events)-1].Name)
+
+	var got []graphQLProgress
+
+	for _, e := range events[:len(events)-1] {
+		require.Equal(t, "next", e.Name)
+
+		var payload struct {
+			Data struct {
+				Progress graphQLProgress `json:"progress"`
+			} `json:"data"`
+		}
+
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &payload))
+		got = append(got, payload.Data.Progress)
+	}
+
+	return got
+}
+
+func requireProgress(t *testing.T, got []graphQLProgress, urls []string) {
+	t.Helper()
+
+	require.Len(t, got, len(urls))
+
+	seen := make(map[string]bool, len(urls))
+
+	for i, p := range got {
+		require.Equal(t, i+1, p.Done)
+		require.Equal(t, len(urls), p.Total)
+		require.Equal(t, http.StatusOK, p.Result.StatusCode)
+		require.Contains(t, urls, p.Result.URL)
+
+		seen[p.Result.URL] = true
+	}
+
+	require.Len(t, seen, len(urls), "every result must be delivered exactly once")
+}
+
+func TestGraphQLProgressSubscription(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	const n = 5
+	urls := makeURLs(t, srv.URL, n)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   1,
+		TimeoutMS: 3000,
+	})
+
+	// подписка может прийти уже после первых результатов - они отдаются повтором
+	requireProgress(t, subscribeProgress(t, c, baseUrl, id), urls)
+}
+
+func TestGraphQLProgressSubscriptionAfterDone(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	const n = 3
+	urls := makeURLs(t, srv.URL, n)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 3000,
+	})
+
+	events := waitJob(t, c, baseUrl, id)
+	require.Equal(t, "done", events[len(events)-1].Name)
+
+	requireProgress(t, subscribeProgress(t, c, baseUrl, id), urls)
+}
@@ -0,0 +1,221 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	grpcService          = "/netcrawler.v1.Crawler/"
+	contentTypeGRPCJSON  = "application/grpc+json"
+	grpcCodeOK           = "0"
+	grpcInvalidArgument  = "3"
+	grpcPermissionDenied = "7"
+	grpcUnauthenticated  = "16"
+)
+
+type grpcCrawlRequest struct {
+	URLs      []string `json:"urls"`
+	Workers   int      `json:"workers"`
+	TimeoutMS int      `json:"timeout_ms"`
+}
+
+type grpcCrawlResult struct {
+	Index      int    `json:"index"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error"`
+	ErrorCode  string `json:"error_code"`
+	Success    bool   `json:"success"`
+}
+
+// h2cClient говорит HTTP/2 без TLS, как gRPC-клиент
+func h2cClient() *http.Client {
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Protocols: &protocols,
+		},
+	}
+}
+
+// grpcCall выполняет gRPC-вызов с кодеком json и возвращает сообщения ответа, grpc-status и заголовки
+func grpcCall(t *testing.T, c *http.Client, baseURL *url.URL, method, key string, msg any) (messages [][]byte, code string, header http.Header) {
+	t.Helper()
+
+	payload, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	var frame bytes.Buffer
+	frame.WriteByte(0)
+	require.NoError(t, binary.Write(&frame, binary.BigEndian, uint32(len(payload))))
+	frame.Write(payload)
+
+	req, err := http.NewRequest(http.MethodPost, baseURL.JoinPath(grpcService+method).String(), &frame)
+	require.NoError(t, err)
+
+	req.Header.Set("Content-Type", contentTypeGRPCJSON)
+	req.Header.Set("TE", "trailers")
+
+	if key != "" {
+		req.Header.Set(apiKeyHeader, key)
+	}
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 2, resp.ProtoMajor)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	for len(body) > 0 {
+		require.GreaterOrEqual(t, len(body), 5)
+		require.Zero(t, body[0], "compressed messages are not expected")
+
+		n := binary.BigEndian.Uint32(body[1:5])
+		require.GreaterOrEqual(t, len(body)-5, int(n))
+
+		messages = append(messages, body[5:5+n])
+		body = body[5+n:]
+	}
+
+	code = resp.Trailer.Get("Grpc-Status")
+	if code == "" {
+		// trailers-only ответ
+		code = resp.Header.Get("Grpc-Status")
+	}
+
+	return messages, code, resp.Header
+}
+
+func TestGRPCCrawl(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := h2cClient()
+	srv := newStatusServer(t)
+
+	urls := []string{srv.URL + "/200", srv.URL + "/404", "http://[::1"}
+
+	messages, code, header := grpcCall(t, c, baseUrl, "Crawl", "", grpcCrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	require.Equal(t, grpcCodeOK, code)
+	require.NotEmpty(t, header.Get("X-Crawler-Instance"))
+	require.NotEmpty(t, header.Get(requestIDHeader))
+	require.Len(t, messages, 1)
+
+	var reply struct {
+		Results []grpcCrawlResult `json:"results"`
+	}
+
+	require.NoError(t, json.Unmarshal(messages[0], &reply))
+	require.Len(t, reply.Results, len(urls))
+
+	for i, r := range reply.Results {
+		require.Equal(t, i, r.Index)
+		require.Equal(t, urls[i], r.URL)
+	}
+
+	require.Equal(t, http.StatusOK, reply.Results[0].StatusCode)
+	require.True(t, reply.Results[0].Success)
+	require.Equal(t, http.StatusNotFound, reply.Results[1].StatusCode)
+	require.Equal(t, errorCodeInvalidURL, reply.Results[2].ErrorCode)
+
+	// тот же кэш, что и у HTTP API
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:      urls[:1],
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+
+	m := scrapeMetrics(t, client(), baseUrl)
+	require.InDelta(t, 1, m[`crawler_http_requests_total{code="200",route="/netcrawler.v1.Crawler/Crawl"}`], 0)
+	require.InDelta(t, 1, m["crawler_cache_hits_total"], 0)
+}
+
+func TestGRPCCrawlStream(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := h2cClient()
+	srv := newStatusServer(t)
+
+	const n = 5
+	urls := makeURLs(t, srv.URL, n)
+
+	messages, code, _ := grpcCall(t, c, baseUrl, "CrawlStream", "", grpcCrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	require.Equal(t, grpcCodeOK, code)
+	require.Len(t, messages, n)
+
+	var indexes []int
+	for _, raw := range messages {
+		var r grpcCrawlResult
+		require.NoError(t, json.Unmarshal(raw, &r))
+		require.Equal(t, urls[r.Index], r.URL)
+		require.Equal(t, http.StatusOK, r.StatusCode)
+
+		indexes = append(indexes, r.Index)
+	}
+
+	require.ElementsMatch(t, []int{0, 1, 2, 3, 4}, indexes)
+}
+
+func TestGRPCErrors(t *testing.T) {
+	setAPIKeyRoles(t, map[string]string{
+		"r": roleReader,
+		"s": roleSubmitter,
+	})
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := h2cClient()
+	valid := grpcCrawlRequest{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000}
+
+	for _, method := range []string{"Crawl", "CrawlStream"} {
+		_, code, _ := grpcCall(t, c, baseUrl, method, "", valid)
+		require.Equal(t, grpcUnauthenticated, code, method)
+
+		_, code, _ = grpcCall(t, c, baseUrl, method, "r", valid)
+		require.Equal(t, grpcPermissionDenied, code, method)
+
+		_, code, _ = grpcCall(t, c, baseUrl, method, "s", grpcCrawlRequest{URLs: valid.URLs, Workers: 0, TimeoutMS: 1000})
+		require.Equal(t, grpcInvalidArgument, code, method)
+	}
+
+	// HTTP/1.1 API по-прежнему доступен на том же адресе
+	resp := postWithKey(t, client(), constructCrawlPath(t, baseUrl), "s", CrawlRequest{
+		URLs:      []string{"http://[::1"},
+		Workers:   1,
+		TimeoutMS: 1000,
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 1, resp.ProtoMajor)
+}
@@ -0,0 +1,124 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newMethodServer запоминает методы запросов; /no-head отвечает 405 на HEAD
+func newMethodServer(t *testing.T) (srv *httptest.Server, methods func() []string) {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		if r.URL.Path == "/no-head" && r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("X-Robots-Tag", "noindex")
+		w.WriteHeader(http.StatusAccepted)
+
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(bytes.Repeat([]byte("x"), 1<<20))
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	methods = func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		out := append([]string(nil), seen...)
+		seen = seen[:0]
+
+		return out
+	}
+
+	return srv, methods
+}
+
+func TestCrawlHeadMode(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, methods := newMethodServer(t)
+
+	urls := []string{srv.URL + "/page", srv.URL + "/no-head"}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:       urls,
+		Workers:    1,
+		TimeoutMS:  2000,
+		Mode:       "head",
+		RobotsMeta: true,
+	})
+
+	require.Len(t, got, 2)
+	for i, r := range got {
+		require.Equal(t, urls[i], r.URL)
+		require.Empty(t, r.Error)
+		require.Equal(t, http.StatusAccepted, r.StatusCode)
+		require.Equal(t, []string{"noindex"}, r.Robots)
+	}
+
+	require.Equal(t, []string{"HEAD /page", "HEAD /no-head", "GET /no-head"}, methods())
+
+	// ответ HEAD не подменяет GET в кэше
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls[:1],
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusAccepted, got[0].StatusCode)
+	require.Equal(t, []string{"GET /page"}, methods())
+
+	// а повторный HEAD берётся из кэша
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls[:1],
+		Workers:   1,
+		TimeoutMS: 2000,
+		Mode:      "head",
+	})
+
+	require.Len(t, got, 1)
+	require.Empty(t, methods())
+}
+
+func TestCrawlHeadModeValidation(t *testing.T) {
+	setRenderBackend(t, &fakeRenderer{})
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, req := range []CrawlRequest{
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, Mode: "head", IncludeBody: true},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, Mode: "head", Depth: 1},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, Mode: "head", Render: true},
+	} {
+		resp := postCrawl(t, c, baseUrl, req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+}
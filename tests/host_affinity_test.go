@@ -0,0 +1,125 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerForHostConsistent(t *testing.T) {
+	const (
+		hosts   = 10_000
+		workers = 16
+	)
+
+	counts := make([]int, workers)
+	moved := 0
+
+	for i := range hosts {
+		host := "host-" + strconv.Itoa(i) + ".example.com:443"
+
+		w := workerForHost(host, workers)
+		require.GreaterOrEqual(t, w, 0)
+		require.Less(t, w, workers)
+		require.Equal(t, w, workerForHost(host, workers))
+
+		counts[w]++
+
+		// при добавлении воркера хост либо остаётся, либо уезжает в новый воркер
+		next := workerForHost(host, workers+1)
+		if next != w {
+			require.Equal(t, workers, next, host)
+			moved++
+		}
+	}
+
+	for w, n := range counts {
+		require.InDelta(t, hosts/workers, n, hosts/workers/4, "worker %d", w)
+	}
+
+	require.InDelta(t, hosts/(workers+1), moved, hosts/(workers+1)/4)
+
+	require.Zero(t, workerForHost("any.example.com:443", 1))
+}
+
+func TestCrawlHostAffinity(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	const (
+		hosts   = 4
+		perHost = 8
+	)
+
+	var (
+		urls  []string
+		peaks []*atomic.Int64
+	)
+
+	for range hosts {
+		srv, peak := newConcurrencyTrackingServer(t, 10*time.Millisecond)
+		t.Cleanup(srv.Close)
+
+		urls = append(urls, makeURLs(t, srv.URL, perHost)...)
+		peaks = append(peaks, peak)
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:         urls,
+		Workers:      hosts,
+		TimeoutMS:    5000,
+		HostAffinity: true,
+	})
+
+	require.Len(t, got, len(urls))
+	for i := range urls {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Equal(t, http.StatusNoContent, got[i].StatusCode)
+	}
+
+	// хост обслуживается одним воркером, поэтому запросы к нему не пересекаются
+	for i, peak := range peaks {
+		require.EqualValues(t, 1, peak.Load(), "host %d", i)
+	}
+}
+
+func TestCrawlHostAffinityReusesConnection(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var conns atomic.Int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:         makeURLs(t, srv.URL, 20),
+		Workers:      8,
+		TimeoutMS:    5000,
+		HostAffinity: true,
+	})
+
+	require.Len(t, got, 20)
+	require.EqualValues(t, 1, conns.Load())
+}
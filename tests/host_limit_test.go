@@ -0,0 +1,227 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newConcurrencyTrackingServer(t *testing.T, delay time.Duration) (srv *httptest.Server, peak *atomic.Int64) {
+	t.Helper()
+
+	var inFlight atomic.Int64
+	peak = &atomic.Int64{}
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cur := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			old := peak.Load()
+			if cur <= old || peak.CompareAndSwap(old, cur) {
+				break
+			}
+		}
+
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	return srv, peak
+}
+
+func TestCrawlMaxConcurrentPerHost(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	first, firstPeak := newConcurrencyTrackingServer(t, 50*time.Millisecond)
+	t.Cleanup(first.Close)
+
+	second, secondPeak := newConcurrencyTrackingServer(t, 50*time.Millisecond)
+	t.Cleanup(second.Close)
+
+	const (
+		n     = 20
+		limit = 3
+	)
+
+	urls := append(makeURLs(t, first.URL, n), makeURLs(t, second.URL, n)...)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:                 urls,
+		Workers:              2 * n,
+		TimeoutMS:            5000,
+		MaxConcurrentPerHost: limit,
+	})
+
+	require.Len(t, got, len(urls))
+	for i := range urls {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Empty(t, got[i].Error)
+		require.Equal(t, http.StatusNoContent, got[i].StatusCode)
+	}
+
+	require.LessOrEqual(t, firstPeak.Load(), int64(limit))
+	require.LessOrEqual(t, secondPeak.Load(), int64(limit))
+
+	// hint: лимит на один хост не должен мешать параллельно ходить в другой
+	require.Equal(t, int64(limit), firstPeak.Load())
+	require.Equal(t, int64(limit), secondPeak.Load())
+}
+
+func TestCrawlMaxConcurrentPerHostTimeout(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv, peak := newConcurrencyTrackingServer(t, 300*time.Millisecond)
+	t.Cleanup(srv.Close)
+
+	const n = 10
+	urls := makeURLs(t, srv.URL, n)
+
+	start := time.Now()
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:                 urls,
+		Workers:              n,
+		TimeoutMS:            500,
+		MaxConcurrentPerHost: 1,
+	})
+
+	require.Less(t, time.Since(start), 2*time.Second)
+	require.Len(t, got, n)
+	require.EqualValues(t, 1, peak.Load())
+
+	timedOut := 0
+	for i := range got {
+		if got[i].Error != "" {
+			require.Contains(t, got[i].Error, "timeout exceeded")
+			timedOut++
+		}
+	}
+
+	require.Positive(t, timedOut)
+}
+
+func TestCrawlMaxConcurrentPerHostNegative(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:                 []string{"http://example.com"},
+		Workers:              1,
+		TimeoutMS:            1000,
+		MaxConcurrentPerHost: -1,
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCrawlMaxPerHostAlias(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv, peak := newConcurrencyTrackingServer(t, 50*time.Millisecond)
+	t.Cleanup(srv.Close)
+
+	const limit = 2
+
+	urls := makeURLs(t, srv.URL, 10)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:       urls,
+		Workers:    len(urls),
+		TimeoutMS:  5000,
+		MaxPerHost: limit,
+	})
+
+	require.Len(t, got, len(urls))
+	require.Equal(t, int64(limit), peak.Load())
+
+	// одинаковые значения не противоречат друг другу
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:                 makeURLs(t, srv.URL+"/same", 2),
+		Workers:              2,
+		TimeoutMS:            5000,
+		MaxPerHost:           limit,
+		MaxConcurrentPerHost: limit,
+	})
+
+	require.Len(t, got, 2)
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:                 urls,
+		Workers:              1,
+		TimeoutMS:            1000,
+		MaxPerHost:           limit,
+		MaxConcurrentPerHost: limit + 1,
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:       urls,
+		Workers:    1,
+		TimeoutMS:  1000,
+		MaxPerHost: -1,
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCrawlMaxPerHostServerDefault(t *testing.T) {
+	const defaultLimit = 2
+
+	t.Setenv("CRAWLER_MAX_PER_HOST", strconv.Itoa(defaultLimit))
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv, peak := newConcurrencyTrackingServer(t, 50*time.Millisecond)
+	t.Cleanup(srv.Close)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL+"/default", 10),
+		Workers:   10,
+		TimeoutMS: 5000,
+	})
+
+	require.Len(t, got, 10)
+	require.Equal(t, int64(defaultLimit), peak.Load())
+
+	// явное значение из запроса заменяет значение по умолчанию
+	other, otherPeak := newConcurrencyTrackingServer(t, 50*time.Millisecond)
+	t.Cleanup(other.Close)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:       makeURLs(t, other.URL+"/explicit", 10),
+		Workers:    10,
+		TimeoutMS:  5000,
+		MaxPerHost: 5,
+	})
+
+	require.Len(t, got, 10)
+	require.Equal(t, int64(5), otherPeak.Load())
+}
+
+func TestCrawlMaxPerHostInvalidServerDefault(t *testing.T) {
+	t.Setenv("CRAWLER_MAX_PER_HOST", "many")
+
+	requireStartError(t, New())
+}
@@ -0,0 +1,142 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostVariantKey(t *testing.T) {
+	same := [][]string{
+		{"http://example.com/a?x=1", "https://example.com/a?x=1", "https://www.example.com/a?x=1", "http://www.example.com:80/a?x=1"},
+		{"https://example.com:8443/", "http://www.example.com:8443/"},
+	}
+
+	for _, group := range same {
+		var keys []string
+		for _, raw := range group {
+			normalized, err := normalizeURL(raw)
+			require.NoError(t, err)
+
+			keys = append(keys, hostVariantKey(normalized))
+		}
+
+		for i := range keys {
+			require.Equal(t, keys[0], keys[i], "%q vs %q", group[0], group[i])
+		}
+	}
+
+	different := [][2]string{
+		{"https://example.com/a", "https://example.com/b"},
+		{"https://example.com/", "https://example.com:8443/"},
+		{"http://example.com:443/", "https://example.com/"},
+		{"https://www2.example.com/", "https://example.com/"},
+		{"https://wwwexample.com/", "https://example.com/"},
+	}
+
+	for _, pair := range different {
+		a, err := normalizeURL(pair[0])
+		require.NoError(t, err)
+
+		b, err := normalizeURL(pair[1])
+		require.NoError(t, err)
+
+		require.NotEqual(t, hostVariantKey(a), hostVariantKey(b), "%q vs %q", pair[0], pair[1])
+	}
+}
+
+func TestMergeHostVariants(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	// www.localhost и https-вариант не должны понадобиться: их обходит первый вариант
+	port := u.Port()
+	urls := []string{
+		"http://localhost:" + port + "/page",
+		"https://localhost:" + port + "/page",
+		"http://www.localhost:" + port + "/page",
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:              urls,
+		Workers:           3,
+		TimeoutMS:         2000,
+		MergeHostVariants: true,
+	})
+
+	require.Len(t, got, len(urls))
+
+	for i := range urls {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Empty(t, got[i].Error)
+		require.Equal(t, http.StatusAccepted, got[i].StatusCode)
+	}
+
+	require.EqualValues(t, 1, hits.Load())
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:              []string{"https://www.localhost:" + port + "/page"},
+		Workers:           1,
+		TimeoutMS:         2000,
+		MergeHostVariants: true,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusAccepted, got[0].StatusCode)
+	require.EqualValues(t, 1, hits.Load())
+}
+
+func TestMergeHostVariantsDisabled(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	urls := []string{
+		"http://localhost:" + u.Port() + "/page",
+		"https://localhost:" + u.Port() + "/page",
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, len(urls))
+	require.Equal(t, http.StatusAccepted, got[0].StatusCode)
+
+	// https-запрос к plain-серверу проваливает handshake
+	require.NotEmpty(t, got[1].Error)
+	require.EqualValues(t, 1, hits.Load())
+}
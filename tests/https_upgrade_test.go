@@ -0,0 +1,199 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newDualServer слушает один порт: TLS-соединения проксируются в httpsHandler, остальные обслуживает httpHandler.
+// Так у http-урла есть https-версия на том же порту. Возвращает host:port.
+func newDualServer(t *testing.T, httpHandler, httpsHandler http.Handler) string {
+	t.Helper()
+
+	tlsSrv := httptest.NewTLSServer(httpsHandler)
+	t.Cleanup(tlsSrv.Close)
+
+	trustServer(t, tlsSrv)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	// plain не закрывается: отправители и Accept завершаются по done
+	plain := make(chan net.Conn)
+	done := make(chan struct{})
+	plainLn := &chanListener{Listener: ln, conns: plain, done: done}
+
+	plainSrv := &http.Server{Handler: httpHandler}
+	go func() {
+		_ = plainSrv.Serve(plainLn)
+	}()
+
+	t.Cleanup(func() {
+		close(done)
+		ln.Close()
+		plainSrv.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				br := bufio.NewReader(conn)
+
+				first, err := br.Peek(1)
+				if err != nil {
+					conn.Close()
+					return
+				}
+
+				buffered := &peekedConn{Conn: conn, r: br}
+
+				// 0x16 - TLS handshake record
+				if first[0] != 0x16 {
+					select {
+					case plain <- buffered:
+					case <-done:
+						conn.Close()
+					}
+
+					return
+				}
+
+				upstream, err := net.Dial("tcp", tlsSrv.Listener.Addr().String())
+				if err != nil {
+					conn.Close()
+					return
+				}
+
+				go func() {
+					_, _ = io.Copy(upstream, buffered)
+					upstream.Close()
+				}()
+
+				_, _ = io.Copy(conn, upstream)
+				conn.Close()
+			}()
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+type peekedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *peekedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+type chanListener struct {
+	net.Listener
+	conns chan net.Conn
+	done  chan struct{}
+}
+
+func (l *chanListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func TestCrawlHTTPSUpgrade(t *testing.T) {
+	httpHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/redirect") {
+			http.Redirect(w, r, "https://"+r.Host+r.URL.Path, http.StatusMovedPermanently)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	httpsHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/broken") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	host := newDualServer(t, httpHandler, httpsHandler)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	urls := []string{
+		"http://" + host + "/redirect",
+		"http://" + host + "/plain",
+		"http://" + host + "/broken",
+		"https://" + host + "/secure",
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:              urls,
+		Workers:           len(urls),
+		TimeoutMS:         3000,
+		CheckHTTPSUpgrade: true,
+	})
+
+	require.Len(t, got, len(urls))
+	for i := range urls {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Empty(t, got[i].Error)
+		require.Equal(t, http.StatusOK, got[i].StatusCode)
+	}
+
+	require.Equal(t, &HTTPSUpgrade{StatusCode: http.StatusOK, Works: true, Redirects: true}, got[0].HTTPSUpgrade)
+	require.Equal(t, &HTTPSUpgrade{StatusCode: http.StatusOK, Works: true}, got[1].HTTPSUpgrade)
+	require.Equal(t, &HTTPSUpgrade{StatusCode: http.StatusInternalServerError}, got[2].HTTPSUpgrade)
+	require.Nil(t, got[3].HTTPSUpgrade)
+}
+
+func TestCrawlHTTPSUpgradeUnavailable(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:              []string{srv.URL},
+		Workers:           1,
+		TimeoutMS:         3000,
+		CheckHTTPSUpgrade: true,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+
+	require.NotNil(t, got[0].HTTPSUpgrade)
+	require.NotEmpty(t, got[0].HTTPSUpgrade.Error)
+	require.Zero(t, got[0].HTTPSUpgrade.StatusCode)
+	require.False(t, got[0].HTTPSUpgrade.Works)
+	require.False(t, got[0].HTTPSUpgrade.Redirects)
+}
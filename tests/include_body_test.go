@@ -0,0 +1,175 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newBodyServer(t *testing.T, bodies map[string][]byte) (srv *httptest.Server, hits *atomic.Int64) {
+	t.Helper()
+
+	hits = &atomic.Int64{}
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, _ = w.Write(body)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv, hits
+}
+
+func TestCrawlIncludeBody(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	const limit = 101
+
+	text := []byte("hello, crawler")
+	binary := []byte{0xff, 0xfe, 0x00, 0x01, 0x80}
+	large := bytes.Repeat([]byte("a"), 4*limit)
+	cyrillic := []byte(strings.Repeat("ж", limit)) // по 2 байта на символ
+
+	srv, _ := newBodyServer(t, map[string][]byte{
+		"/text":     text,
+		"/binary":   binary,
+		"/large":    large,
+		"/cyrillic": cyrillic,
+	})
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:         []string{srv.URL + "/text", srv.URL + "/binary", srv.URL + "/large", srv.URL + "/cyrillic"},
+		Workers:      4,
+		TimeoutMS:    2000,
+		IncludeBody:  true,
+		MaxBodyBytes: limit,
+	})
+
+	require.Len(t, got, 4)
+
+	require.Equal(t, string(text), got[0].Body)
+	require.Equal(t, "utf8", got[0].BodyEncoding)
+	require.False(t, got[0].BodyTruncated)
+
+	require.Equal(t, base64.StdEncoding.EncodeToString(binary), got[1].Body)
+	require.Equal(t, "base64", got[1].BodyEncoding)
+	require.False(t, got[1].BodyTruncated)
+
+	require.Equal(t, string(large[:limit]), got[2].Body)
+	require.Equal(t, "utf8", got[2].BodyEncoding)
+	require.True(t, got[2].BodyTruncated)
+
+	// 101 байт режут последнюю "ж" пополам: она отбрасывается, а не превращает тело в base64
+	require.Equal(t, "utf8", got[3].BodyEncoding)
+	require.True(t, got[3].BodyTruncated)
+	require.True(t, utf8.ValidString(got[3].Body))
+	require.Equal(t, string(cyrillic[:limit-1]), got[3].Body)
+}
+
+func TestCrawlIncludeBodyDefaultLimit(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	huge := bytes.Repeat([]byte("b"), 2*defaultMaxBodyBytes)
+	srv, _ := newBodyServer(t, map[string][]byte{"/huge": huge})
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:        []string{srv.URL + "/huge"},
+		Workers:     1,
+		TimeoutMS:   2000,
+		IncludeBody: true,
+	})
+
+	require.Len(t, got, 1)
+	require.Len(t, got[0].Body, defaultMaxBodyBytes)
+	require.True(t, got[0].BodyTruncated)
+}
+
+func TestCrawlIncludeBodyCache(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	body := bytes.Repeat([]byte("c"), 1000)
+	srv, hits := newBodyServer(t, map[string][]byte{"/page": body})
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL + "/page"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	}
+
+	got := crawl(t, c, baseUrl, req)
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].Body)
+
+	// в кэше нет тела - урл загружается заново
+	req.IncludeBody = true
+	req.MaxBodyBytes = 100
+
+	got = crawl(t, c, baseUrl, req)
+	require.Len(t, got, 1)
+	require.Equal(t, string(body[:100]), got[0].Body)
+	require.EqualValues(t, 2, hits.Load())
+
+	// меньше или столько же - из кэша
+	req.MaxBodyBytes = 50
+
+	got = crawl(t, c, baseUrl, req)
+	require.Len(t, got, 1)
+	require.Equal(t, string(body[:50]), got[0].Body)
+	require.True(t, got[0].BodyTruncated)
+	require.EqualValues(t, 2, hits.Load())
+
+	// больше сохранённого - снова из сети
+	req.MaxBodyBytes = 2000
+
+	got = crawl(t, c, baseUrl, req)
+	require.Len(t, got, 1)
+	require.Equal(t, string(body), got[0].Body)
+	require.False(t, got[0].BodyTruncated)
+	require.EqualValues(t, 3, hits.Load())
+
+	// всё тело уже сохранено - любой лимит из кэша
+	req.MaxBodyBytes = 5000
+
+	got = crawl(t, c, baseUrl, req)
+	require.Len(t, got, 1)
+	require.Equal(t, string(body), got[0].Body)
+	require.EqualValues(t, 3, hits.Load())
+}
+
+func TestCrawlIncludeBodyValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, req := range []CrawlRequest{
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, MaxBodyBytes: 10},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, IncludeBody: true, MaxBodyBytes: -1},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, IncludeBody: true, MaxBodyBytes: maxBodyBytesLimit + 1},
+	} {
+		resp := postCrawl(t, c, baseUrl, req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+}
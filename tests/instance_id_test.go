@@ -0,0 +1,219 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	instanceIDEnv    = "CRAWLER_INSTANCE_ID"
+	instanceHeader   = "X-Crawler-Instance"
+	instancePath     = "/admin/instance"
+	generatedPattern = `^[0-9a-f]{16}$`
+)
+
+type instanceInfo struct {
+	ID        string    `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+func getInstance(t *testing.T, c *http.Client, baseURL *url.URL) instanceInfo {
+	t.Helper()
+
+	resp, err := c.Get(baseURL.JoinPath(instancePath).String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var info instanceInfo
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+	require.Equal(t, info.ID, resp.Header.Get(instanceHeader))
+
+	return info
+}
+
+func TestInstanceIDFromEnv(t *testing.T) {
+	const id = "crawler-eu-1.prod"
+
+	t.Setenv(instanceIDEnv, id)
+
+	clk := newFakeClock()
+	started := clk.Now()
+
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	info := getInstance(t, c, baseUrl)
+	require.Equal(t, id, info.ID)
+	require.True(t, started.Equal(info.StartedAt))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	req := CrawlRequest{
+		URLs:      makeURLs(t, srv.URL, 2),
+		Workers:   1,
+		TimeoutMS: 2000,
+	}
+
+	resp := postCrawl(t, c, baseUrl, req)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, id, resp.Header.Get(instanceHeader))
+
+	jobID := submitJob(t, c, baseUrl, req)
+	events := waitJob(t, c, baseUrl, jobID)
+
+	var done struct {
+		Instance string `json:"instance"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(events[len(events)-1].Data), &done))
+	require.Equal(t, id, done.Instance)
+
+	snapResp, err := c.Get(baseUrl.JoinPath(crawlPath, jobsPath, jobID).String())
+	require.NoError(t, err)
+	defer snapResp.Body.Close()
+
+	require.Equal(t, http.StatusOK, snapResp.StatusCode)
+	require.Equal(t, id, snapResp.Header.Get(instanceHeader))
+
+	var snapshot struct {
+		Status   string `json:"status"`
+		Instance string `json:"instance"`
+	}
+
+	require.NoError(t, json.NewDecoder(snapResp.Body).Decode(&snapshot))
+	require.Equal(t, "done", snapshot.Status)
+	require.Equal(t, id, snapshot.Instance)
+
+	// даже ошибки несут заголовок
+	resp = postCrawl(t, c, baseUrl, CrawlRequest{URLs: []string{"http://127.0.0.1/"}, Workers: -1, TimeoutMS: 1000})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, id, resp.Header.Get(instanceHeader))
+}
+
+func TestInstanceIDInTargets(t *testing.T) {
+	const id = "crawler-eu-2"
+
+	t.Setenv(instanceIDEnv, id)
+	t.Setenv(jobHistoryEnv, "1")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	urls := []string{srv.URL + "/200", srv.URL + "/404"}
+	waitJob(t, c, baseUrl, submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+	}))
+
+	resp := getTargets(t, c, baseUrl, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, id, resp.Header.Get(instanceHeader))
+
+	var all []target
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&all))
+	require.Len(t, all, len(urls))
+
+	for _, tg := range all {
+		require.Equal(t, id, tg.Instance, tg.URL)
+	}
+}
+
+func TestInstanceRequiresReader(t *testing.T) {
+	setAPIKeyRoles(t, map[string]string{
+		"r": roleReader,
+		"s": roleSubmitter,
+		"a": roleAdmin,
+	})
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	target := baseUrl.JoinPath(instancePath)
+
+	require.Equal(t, http.StatusUnauthorized, getWithKey(t, c, target, "").StatusCode)
+	require.Equal(t, http.StatusUnauthorized, getWithKey(t, c, target, "unknown").StatusCode)
+
+	// в отличие от остальных /admin/*, хватает роли reader
+	for _, key := range []string{"r", "s", "a"} {
+		resp := getWithKey(t, c, target, key)
+		require.Equal(t, http.StatusOK, resp.StatusCode, key)
+
+		var info instanceInfo
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+		require.Equal(t, resp.Header.Get(instanceHeader), info.ID)
+	}
+}
+
+func TestInstanceIDGenerated(t *testing.T) {
+	t.Setenv(instanceIDEnv, "")
+	t.Setenv(stateFileEnv, "")
+
+	first, stopFirst := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopFirst)
+
+	second, stopSecond := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopSecond)
+
+	c := client()
+
+	a := getInstance(t, c, first)
+	b := getInstance(t, c, second)
+
+	require.Regexp(t, regexp.MustCompile(generatedPattern), a.ID)
+	require.Regexp(t, regexp.MustCompile(generatedPattern), b.ID)
+	require.NotEqual(t, a.ID, b.ID)
+}
+
+func TestInstanceIDSurvivesRestart(t *testing.T) {
+	t.Setenv(instanceIDEnv, "")
+	t.Setenv(stateFileEnv, filepath.Join(t.TempDir(), "state.json"))
+
+	c := client()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	baseUrl, stopWait := startCrawlerServer(ctx, t)
+
+	before := getInstance(t, c, baseUrl)
+
+	cancel()
+	stopWait()
+
+	baseUrl, stopWait = startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	after := getInstance(t, c, baseUrl)
+	require.Equal(t, before.ID, after.ID)
+}
+
+func TestInstanceIDInvalid(t *testing.T) {
+	for _, id := range []string{"has space", "slash/inside", strings.Repeat("a", 65)} {
+		t.Setenv(instanceIDEnv, id)
+
+		requireStartError(t, New(), "%q", id)
+	}
+}
@@ -0,0 +1,124 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const stickyCookie = "sticky"
+
+func newStickyServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/set" {
+			http.SetCookie(w, &http.Cookie{Name: stickyCookie, Value: "node-1", Path: "/"})
+			w.WriteHeader(http.StatusOK)
+
+			return
+		}
+
+		if _, err := r.Cookie(stickyCookie); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCrawlIsolatedTransportCookies(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStickyServer(t)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:              []string{srv.URL + "/set", srv.URL + "/check-1"},
+		Workers:           1,
+		TimeoutMS:         2000,
+		IsolatedTransport: true,
+	})
+
+	require.Len(t, got, 2)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Equal(t, http.StatusNoContent, got[1].StatusCode)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:              []string{srv.URL + "/check-2"},
+		Workers:           1,
+		TimeoutMS:         2000,
+		IsolatedTransport: true,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusUnauthorized, got[0].StatusCode, "cookies must not leak across isolated crawls")
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/set", srv.URL + "/check-3"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 2)
+	require.Equal(t, http.StatusUnauthorized, got[1].StatusCode, "shared transport must not keep cookies")
+}
+
+func TestCrawlIsolatedTransportConnections(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var opened, closed atomic.Int64
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			opened.Add(1)
+		case http.StateClosed:
+			closed.Add(1)
+		}
+	}
+
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	const n = 3
+
+	for i := range n {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:              makeURLs(t, srv.URL+"/"+string(rune('a'+i)), 5),
+			Workers:           1,
+			TimeoutMS:         2000,
+			IsolatedTransport: true,
+		})
+
+		require.Len(t, got, 5)
+		for j := range got {
+			require.Equal(t, http.StatusNoContent, got[j].StatusCode)
+		}
+	}
+
+	require.EqualValues(t, n, opened.Load(), "expected one connection per isolated crawl")
+
+	require.Eventually(t, func() bool {
+		return closed.Load() == n
+	}, time.Second, 20*time.Millisecond, "isolated connections must be closed when crawl finishes")
+}
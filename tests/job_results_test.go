@@ -0,0 +1,138 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type jobResult struct {
+	CrawlResponse
+	Seq   int `json:"seq"`
+	Index int `json:"index"`
+}
+
+type jobResultsPage struct {
+	Results   []jobResult `json:"results"`
+	NextAfter int         `json:"next_after"`
+	Finished  bool        `json:"finished"`
+}
+
+func getJobResults(t *testing.T, c *http.Client, baseURL *url.URL, id string, query url.Values) *http.Response {
+	t.Helper()
+
+	u := constructJobsPath(t, baseURL, id, "results")
+	u.RawQuery = query.Encode()
+
+	resp, err := c.Get(u.String())
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	return resp
+}
+
+func TestJobResultsPagination(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	const (
+		n     = 30
+		limit = 4
+	)
+
+	urls := makeURLs(t, srv.URL, n)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   3,
+		TimeoutMS: 5000,
+	})
+
+	var (
+		after   int
+		results []jobResult
+		seen    = make(map[int]bool)
+	)
+
+	for {
+		resp := getJobResults(t, c, baseUrl, id, url.Values{
+			"after": {strconv.Itoa(after)},
+			"limit": {strconv.Itoa(limit)},
+		})
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var page jobResultsPage
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+		require.LessOrEqual(t, len(page.Results), limit)
+
+		for _, r := range page.Results {
+			require.Equal(t, after+1, r.Seq, "seq must be contiguous across pages")
+			require.False(t, seen[r.Index], "duplicate index %d", r.Index)
+
+			seen[r.Index] = true
+			after = r.Seq
+		}
+
+		require.Equal(t, after, page.NextAfter)
+		results = append(results, page.Results...)
+
+		if page.Finished {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.Len(t, results, n)
+	for _, r := range results {
+		require.Equal(t, urls[r.Index], r.URL)
+		require.Equal(t, http.StatusNoContent, r.StatusCode)
+	}
+}
+
+func TestJobResultsInvalidParams(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://example.com:abc"},
+		Workers:   1,
+		TimeoutMS: 1000,
+	})
+
+	for _, q := range []url.Values{
+		{"after": {"-1"}},
+		{"after": {"abc"}},
+		{"limit": {"0"}},
+		{"limit": {"1001"}},
+	} {
+		resp := getJobResults(t, c, baseUrl, id, q)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "query %v", q)
+	}
+
+	resp := getJobResults(t, c, baseUrl, "unknown", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,129 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func jobResultFor(t *testing.T, c *http.Client, baseURL *url.URL, req CrawlRequest) CrawlResponse {
+	t.Helper()
+
+	id := submitJob(t, c, baseURL, req)
+
+	var got []CrawlResponse
+	for _, e := range waitJob(t, c, baseURL, id) {
+		if e.Name != "result" {
+			continue
+		}
+
+		var r sseResult
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &r))
+		got = append(got, r.CrawlResponse)
+	}
+
+	require.Len(t, got, 1)
+	return got[0]
+}
+
+func TestLatencyVsBaseline(t *testing.T) {
+	t.Setenv(jobHistoryEnv, "1")
+
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var delayMS atomic.Int64
+	delayMS.Store(20)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Duration(delayMS.Load()) * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL + "/page"},
+		Workers:   1,
+		TimeoutMS: 5000,
+	}
+
+	for range latencyBaselineMinSamples {
+		r := jobResultFor(t, c, baseUrl, req)
+		require.Equal(t, http.StatusOK, r.StatusCode)
+		require.Nil(t, r.LatencyVsBaseline, "not enough history yet")
+
+		// следующая задача не должна попасть в кэш ответов
+		clk.Advance(cacheTTL + time.Second)
+	}
+
+	delayMS.Store(200)
+
+	r := jobResultFor(t, c, baseUrl, req)
+	require.NotNil(t, r.LatencyVsBaseline)
+
+	b := r.LatencyVsBaseline
+	require.Equal(t, latencyBaselineMinSamples, b.Samples)
+	require.GreaterOrEqual(t, b.MedianMS, 20.0)
+	require.Less(t, b.MedianMS, 150.0)
+	require.Greater(t, b.ChangePercent, float64(latencyRegressionPercent))
+	require.True(t, b.Regression)
+
+	clk.Advance(cacheTTL + time.Second)
+
+	// /crawl тоже аннотируется: в истории уже 4 замера, медиана всё ещё около 20ms
+	delayMS.Store(20)
+
+	got := crawl(t, c, baseUrl, req)
+	require.Len(t, got, 1)
+	require.NotNil(t, got[0].LatencyVsBaseline)
+	require.Equal(t, latencyBaselineMinSamples+1, got[0].LatencyVsBaseline.Samples)
+	require.False(t, got[0].LatencyVsBaseline.Regression)
+
+	// история старше окна не учитывается
+	clk.Advance(latencyBaselineWindow + time.Hour)
+
+	r = jobResultFor(t, c, baseUrl, req)
+	require.Equal(t, http.StatusOK, r.StatusCode)
+	require.Nil(t, r.LatencyVsBaseline)
+}
+
+func TestLatencyVsBaselineWithoutHistory(t *testing.T) {
+	t.Setenv(jobHistoryEnv, "")
+
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL + "/page"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	}
+
+	for range latencyBaselineMinSamples + 1 {
+		r := jobResultFor(t, c, baseUrl, req)
+		require.Nil(t, r.LatencyVsBaseline)
+
+		clk.Advance(cacheTTL + time.Second)
+	}
+}
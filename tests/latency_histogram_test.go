@@ -0,0 +1,131 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type hostLatency struct {
+	Host      string    `json:"host"`
+	Count     int       `json:"count"`
+	BucketsMS []float64 `json:"buckets_ms"`
+	Counts    []int     `json:"counts"`
+}
+
+type slowURL struct {
+	Index   int     `json:"index"`
+	URL     string  `json:"url"`
+	TotalMS float64 `json:"total_ms"`
+}
+
+type latencySummary struct {
+	Hosts   []hostLatency `json:"hosts"`
+	Slowest []slowURL     `json:"slowest"`
+}
+
+func TestJobLatencyHistogram(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	const slow = 300 * time.Millisecond
+
+	mixed := newLatencyServer(t, slow)
+	fast := newStatusServer(t)
+
+	urls := []string{
+		mixed.URL + "/fast/1",
+		mixed.URL + "/slow/1",
+		mixed.URL + "/fast/2",
+		mixed.URL + "/slow/2",
+		fast.URL + "/200",
+		fast.URL + "/204",
+	}
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:             urls,
+		Workers:          len(urls),
+		TimeoutMS:        5000,
+		LatencyBucketsMS: []float64{100, 1000},
+		SlowestN:         3,
+	})
+
+	events := waitJob(t, c, baseUrl, id)
+	require.NotEmpty(t, events)
+
+	var done struct {
+		Latency latencySummary `json:"latency"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(events[len(events)-1].Data), &done))
+
+	hostOf := func(raw string) string {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		return u.Host
+	}
+
+	want := []hostLatency{
+		{Host: hostOf(mixed.URL), Count: 4, BucketsMS: []float64{100, 1000}, Counts: []int{2, 2, 0}},
+		{Host: hostOf(fast.URL), Count: 2, BucketsMS: []float64{100, 1000}, Counts: []int{2, 0, 0}},
+	}
+
+	if want[0].Host > want[1].Host {
+		want[0], want[1] = want[1], want[0]
+	}
+
+	require.Equal(t, want, done.Latency.Hosts)
+
+	slowest := done.Latency.Slowest
+	require.Len(t, slowest, 3)
+
+	for i, s := range slowest {
+		require.Equal(t, urls[s.Index], s.URL)
+
+		if i > 0 {
+			require.LessOrEqual(t, s.TotalMS, slowest[i-1].TotalMS)
+		}
+	}
+
+	for _, s := range slowest[:2] {
+		require.True(t, strings.Contains(s.URL, "/slow/"), s.URL)
+		require.GreaterOrEqual(t, s.TotalMS, float64(slow.Milliseconds()))
+	}
+}
+
+func TestJobLatencyValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	tooMany := make([]float64, 33)
+	for i := range tooMany {
+		tooMany[i] = float64(i + 1)
+	}
+
+	for _, req := range []CrawlRequest{
+		{LatencyBucketsMS: []float64{100, 100}},
+		{LatencyBucketsMS: []float64{500, 100}},
+		{LatencyBucketsMS: []float64{0, 100}},
+		{LatencyBucketsMS: tooMany},
+		{SlowestN: 101},
+		{SlowestN: -1},
+	} {
+		req.URLs = []string{"http://127.0.0.1/"}
+		req.Workers = 1
+		req.TimeoutMS = 1000
+
+		resp := postWithKey(t, c, constructJobsPath(t, baseUrl), "", req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+}
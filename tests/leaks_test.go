@@ -0,0 +1,146 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const leaksPath = "/debug/leaks"
+
+type leakJob struct {
+	ID      string `json:"id"`
+	Workers int    `json:"workers"`
+	Fetches int    `json:"fetches"`
+}
+
+type leakReport struct {
+	OK         bool      `json:"ok"`
+	ActiveJobs int       `json:"active_jobs"`
+	Workers    int       `json:"workers"`
+	Fetches    int       `json:"fetches"`
+	Jobs       []leakJob `json:"jobs"`
+}
+
+// enablePanicOnLeak включает тестовый режим детектора. Вызывать до старта краулера.
+func enablePanicOnLeak(t *testing.T) {
+	t.Helper()
+
+	prev := panicOnLeak
+	panicOnLeak = true
+
+	t.Cleanup(func() {
+		panicOnLeak = prev
+	})
+}
+
+// readLeaks не вызывает require, поэтому годится и для условий require.Eventually
+func readLeaks(c *http.Client, baseURL *url.URL) (leakReport, error) {
+	var report leakReport
+
+	resp, err := c.Get(baseURL.JoinPath(leaksPath).String())
+	if err != nil {
+		return report, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return report, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&report)
+	return report, err
+}
+
+func getLeaks(t *testing.T, c *http.Client, baseURL *url.URL) leakReport {
+	t.Helper()
+
+	report, err := readLeaks(c, baseURL)
+	require.NoError(t, err)
+
+	return report
+}
+
+func requireNoLeaks(t *testing.T, c *http.Client, baseURL *url.URL) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		r, err := readLeaks(c, baseURL)
+		return err == nil && r.OK && r.ActiveJobs == 0 && r.Workers == 0 && r.Fetches == 0 && len(r.Jobs) == 0
+	}, 2*leakGracePeriod, 20*time.Millisecond)
+}
+
+func TestLeaksDuringJob(t *testing.T) {
+	enablePanicOnLeak(t)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, inFlight, release := newGateServer(t)
+
+	requireNoLeaks(t, c, baseUrl)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL+"/leaks", 4),
+		Workers:   2,
+		TimeoutMS: 5000,
+	})
+
+	require.Eventually(t, func() bool {
+		return inFlight("leaks") == 2
+	}, time.Second, 20*time.Millisecond)
+
+	report := getLeaks(t, c, baseUrl)
+	require.True(t, report.OK)
+	require.Equal(t, 1, report.ActiveJobs)
+	require.Equal(t, 2, report.Workers)
+	require.Equal(t, 2, report.Fetches)
+	require.Equal(t, []leakJob{{ID: id, Workers: 2, Fetches: 2}}, report.Jobs)
+
+	release()
+	waitJob(t, c, baseUrl, id)
+
+	requireNoLeaks(t, c, baseUrl)
+}
+
+func TestLeaksAfterTimeout(t *testing.T) {
+	enablePanicOnLeak(t)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(10 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	const n = 8
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL, n),
+		Workers:   n,
+		TimeoutMS: 200,
+	})
+
+	require.Len(t, got, n)
+	for _, r := range got {
+		require.Equal(t, errorCodeTimeout, r.ErrorCode)
+	}
+
+	// отменённые по таймауту запросы не должны оставлять горутин
+	requireNoLeaks(t, c, baseUrl)
+}
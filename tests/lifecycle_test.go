@@ -0,0 +1,141 @@
+//go:build model_test && !windows
+
+package crawler
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func startRun(ctx context.Context, t *testing.T) (baseURL *url.URL, errCh <-chan error) {
+	t.Helper()
+
+	port := findFreePort(t)
+	ch := make(chan error, 1)
+
+	go func() {
+		defer close(ch)
+		ch <- Run(ctx, port)
+	}()
+
+	baseURL, err := url.Parse("http://127.0.0.1" + port)
+	require.NoError(t, err)
+
+	require.True(t, waitHTTPUp(t, baseURL, serverUpTTL), "failed to start server: %s", baseURL)
+	return baseURL, ch
+}
+
+func waitRunStopped(t *testing.T, errCh <-chan error) {
+	t.Helper()
+
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(serverDownTTL):
+		t.Fatal("Run did not stop in time")
+	}
+}
+
+func TestRunStopsOnSignal(t *testing.T) {
+	for _, sig := range []syscall.Signal{syscall.SIGINT, syscall.SIGTERM} {
+		t.Run(sig.String(), func(t *testing.T) {
+			baseUrl, errCh := startRun(t.Context(), t)
+
+			c := client()
+
+			arrived := make(chan struct{}, 1)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case arrived <- struct{}{}:
+				default:
+				}
+
+				time.Sleep(500 * time.Millisecond)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			t.Cleanup(srv.Close)
+
+			done := crawlAsync(c, baseUrl, CrawlRequest{
+				URLs:      []string{srv.URL},
+				Workers:   1,
+				TimeoutMS: 5000,
+			})
+
+			// сигнал приходит, когда запрос к урлу уже выполняется
+			select {
+			case <-arrived:
+			case <-time.After(5 * time.Second):
+				t.Fatal("crawl did not reach the upstream server")
+			}
+
+			require.NoError(t, syscall.Kill(os.Getpid(), sig))
+
+			// текущий запрос завершается штатно
+			got := awaitCrawl(t, done)
+			require.Len(t, got, 1)
+			require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+
+			waitRunStopped(t, errCh)
+
+			_, err := net.Dial("tcp", baseUrl.Host)
+			require.ErrorContains(t, err, "connection refused")
+		})
+	}
+}
+
+func TestRunStopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	t.Cleanup(cancel)
+
+	baseUrl, errCh := startRun(ctx, t)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+
+	t.Cleanup(srv.Close)
+
+	// фоновая задача не должна задерживать остановку дольше таймаута shutdown
+	submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL},
+		Workers:   1,
+		TimeoutMS: 60_000,
+	})
+
+	cancel()
+	waitRunStopped(t, errCh)
+}
+
+func TestRunAddressInUse(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- Run(t.Context(), ln.Addr().String())
+	}()
+
+	select {
+	case err := <-errCh:
+		require.Error(t, err)
+	case <-time.After(serverDownTTL):
+		t.Fatal("Run must fail when address is in use")
+	}
+}
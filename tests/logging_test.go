@@ -0,0 +1,261 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// captureLogs подменяет logger JSON-логгером уровня DEBUG. Вызывать до старта краулера.
+func captureLogs(t *testing.T) (lines func() []map[string]any) {
+	t.Helper()
+
+	var buf syncBuffer
+
+	prev := logger
+	logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	t.Cleanup(func() {
+		logger = prev
+	})
+
+	return func() []map[string]any {
+		var out []map[string]any
+
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+
+			var entry map[string]any
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Errorf("malformed log line %q: %v", line, err)
+				continue
+			}
+
+			out = append(out, entry)
+		}
+
+		return out
+	}
+}
+
+func logsFor(lines []map[string]any, requestID, msg string) []map[string]any {
+	var out []map[string]any
+
+	for _, l := range lines {
+		if l["request_id"] == requestID && l[slog.MessageKey] == msg {
+			out = append(out, l)
+		}
+	}
+
+	return out
+}
+
+func postWithRequestID(t *testing.T, c *http.Client, target, requestID string, body any) *http.Response {
+	t.Helper()
+
+	raw, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(raw))
+	require.NoError(t, err)
+
+	req.Header.Set("Content-Type", contentTypeJson)
+	req.Header.Set(requestIDHeader, requestID)
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Equal(t, requestID, resp.Header.Get(requestIDHeader))
+
+	return resp
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	lines := captureLogs(t)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+	urls := makeURLs(t, srv.URL, 3)
+
+	body, err := json.Marshal(CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+		Headers:   map[string]string{"Authorization": "Bearer secret-token"},
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, constructCrawlPath(t, baseUrl).String(), bytes.NewReader(body))
+	require.NoError(t, err)
+
+	req.Header.Set("Content-Type", contentTypeJson)
+	req.Header.Set(requestIDHeader, "trace-42")
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "trace-42", resp.Header.Get(requestIDHeader))
+
+	require.Eventually(t, func() bool {
+		return len(logsFor(lines(), "trace-42", "request finished")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	all := lines()
+
+	finished := logsFor(all, "trace-42", "request finished")[0]
+	require.Equal(t, "INFO", finished[slog.LevelKey])
+	require.Equal(t, http.MethodPost, finished["method"])
+	require.Equal(t, crawlPath, finished["route"])
+	require.EqualValues(t, http.StatusOK, finished["status"])
+	require.Contains(t, finished, "duration_ms")
+
+	fetches := logsFor(all, "trace-42", "fetch")
+	require.Len(t, fetches, len(urls))
+
+	var fetched []string
+	for _, f := range fetches {
+		require.Equal(t, "DEBUG", f[slog.LevelKey])
+		require.EqualValues(t, http.StatusOK, f["status_code"])
+		require.Contains(t, f, "worker")
+
+		fetched = append(fetched, f["url"].(string))
+	}
+
+	require.ElementsMatch(t, urls, fetched)
+
+	for _, l := range all {
+		raw, err := json.Marshal(l)
+		require.NoError(t, err)
+		require.NotContains(t, string(raw), "secret-token")
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	lines := captureLogs(t)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	for _, incoming := range []string{"", "has space", strings.Repeat("x", 129)} {
+		body, err := json.Marshal(CrawlRequest{
+			URLs:      []string{srv.URL},
+			Workers:   1,
+			TimeoutMS: 2000,
+		})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, constructCrawlPath(t, baseUrl).String(), bytes.NewReader(body))
+		require.NoError(t, err)
+
+		if incoming != "" {
+			req.Header.Set(requestIDHeader, incoming)
+		}
+
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		id := resp.Header.Get(requestIDHeader)
+		require.Regexp(t, generated, id, incoming)
+
+		require.Eventually(t, func() bool {
+			return len(logsFor(lines(), id, "request finished")) == 1
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	// ошибки тоже получают идентификатор
+	resp, err := c.Get(baseUrl.JoinPath("jobs", "missing", "events").String())
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.Regexp(t, generated, resp.Header.Get(requestIDHeader))
+}
+
+func TestJobLogsCarryRequestID(t *testing.T) {
+	lines := captureLogs(t)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	resp := postWithRequestID(t, c, constructJobsPath(t, baseUrl).String(), "job-req", CrawlRequest{
+		URLs:      makeURLs(t, srv.URL, 2),
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	var created struct {
+		ID string `json:"id"`
+	}
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	waitJob(t, c, baseUrl, created.ID)
+
+	require.Eventually(t, func() bool {
+		return len(logsFor(lines(), "job-req", "job finished")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	all := lines()
+
+	finished := logsFor(all, "job-req", "job finished")[0]
+	require.Equal(t, created.ID, finished["job_id"])
+	require.EqualValues(t, 2, finished["urls"])
+	require.EqualValues(t, 0, finished["failed"])
+
+	fetches := logsFor(all, "job-req", "fetch")
+	require.Len(t, fetches, 2)
+	for _, f := range fetches {
+		require.Equal(t, created.ID, f["job_id"])
+	}
+}
@@ -0,0 +1,148 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	metricsSnapshotEnv = "CRAWLER_METRICS_SNAPSHOT_MS"
+	usagePath          = "/admin/usage"
+)
+
+type hostUsage struct {
+	Host    string `json:"host"`
+	Fetches int64  `json:"fetches"`
+	Bytes   int64  `json:"bytes"`
+	Errors  int64  `json:"errors"`
+}
+
+type usageReport struct {
+	Since   time.Time   `json:"since"`
+	Fetches int64       `json:"fetches"`
+	Bytes   int64       `json:"bytes"`
+	Hosts   []hostUsage `json:"hosts"`
+}
+
+func getUsage(t *testing.T, c *http.Client, baseURL *url.URL) usageReport {
+	t.Helper()
+
+	resp, err := c.Get(baseURL.JoinPath(usagePath).String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var report usageReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+
+	return report
+}
+
+func usageOf(report usageReport, host string) hostUsage {
+	for _, h := range report.Hosts {
+		if h.Host == host {
+			return h
+		}
+	}
+
+	return hostUsage{}
+}
+
+func TestMetricsSnapshotRestart(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	t.Setenv(stateFileEnv, stateFile)
+	t.Setenv(metricsSnapshotEnv, "1000")
+
+	clk := newFakeClock()
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+
+	t.Cleanup(srv.Close)
+
+	host := srv.Listener.Addr().String()
+	closed := closedServerURL(t)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	baseUrl, stopWait := startCrawlerServerWithClock(ctx, t, clk)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      append(makeURLs(t, srv.URL, 2), closed.String()),
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 3)
+	require.Equal(t, errorCodeFetchFailed, got[2].ErrorCode)
+
+	before := getUsage(t, c, baseUrl)
+	require.Equal(t, hostUsage{Host: host, Fetches: 2, Bytes: usageOf(before, host).Bytes}, usageOf(before, host))
+	require.Positive(t, usageOf(before, host).Bytes)
+	require.EqualValues(t, 1, usageOf(before, closed.Host).Errors)
+
+	metrics := scrapeMetrics(t, c, baseUrl)
+	require.Zero(t, metrics["crawler_metrics_snapshot_timestamp_seconds"])
+
+	// периодический снимок пишется без остановки сервера
+	clk.Advance(time.Second)
+
+	require.Eventually(t, func() bool {
+		if _, err := os.Stat(stateFile + ".metrics"); err != nil {
+			return false
+		}
+
+		m, err := readMetrics(c, baseUrl)
+		return err == nil && m["crawler_metrics_snapshot_timestamp_seconds"] > 0
+	}, 2*time.Second, 20*time.Millisecond)
+
+	fetched := metrics["crawler_urls_fetched_total"]
+	require.Positive(t, fetched)
+
+	cancel()
+	stopWait()
+
+	baseUrl, stopWait = startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	require.Equal(t, fetched, scrapeMetrics(t, c, baseUrl)["crawler_urls_fetched_total"])
+
+	after := getUsage(t, c, baseUrl)
+	require.True(t, before.Since.Equal(after.Since))
+	require.Equal(t, before.Fetches, after.Fetches)
+	require.Equal(t, usageOf(before, host), usageOf(after, host))
+
+	// счётчики продолжают расти от сохранённых значений
+	crawl(t, c, baseUrl, CrawlRequest{URLs: []string{srv.URL + "/more"}, Workers: 1, TimeoutMS: 2000})
+
+	require.Equal(t, fetched+1, scrapeMetrics(t, c, baseUrl)["crawler_urls_fetched_total"])
+	require.EqualValues(t, 3, usageOf(getUsage(t, c, baseUrl), host).Fetches)
+}
+
+func TestMetricsSnapshotConfig(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	t.Setenv(stateFileEnv, stateFile)
+
+	for _, v := range []string{"0", "-1", "soon"} {
+		t.Setenv(metricsSnapshotEnv, v)
+		requireStartError(t, New(), v)
+	}
+
+	t.Setenv(metricsSnapshotEnv, "1000")
+
+	require.NoError(t, os.WriteFile(stateFile+".metrics", []byte("{not json"), 0o600))
+	requireStartError(t, New())
+}
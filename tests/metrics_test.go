@@ -0,0 +1,196 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const metricsPath = "/metrics"
+
+// readMetrics не вызывает require, поэтому годится и для условий require.Eventually
+func readMetrics(c *http.Client, baseURL *url.URL) (map[string]float64, error) {
+	resp, err := c.Get(baseURL.JoinPath(metricsPath).String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		return nil, fmt.Errorf("unexpected content type %q", ct)
+	}
+
+	samples := make(map[string]float64)
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		i := strings.LastIndexByte(line, ' ')
+		if i <= 0 {
+			return nil, fmt.Errorf("malformed sample %q", line)
+		}
+
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed sample %q: %w", line, err)
+		}
+
+		samples[line[:i]] = v
+	}
+
+	return samples, scanner.Err()
+}
+
+// scrapeMetrics возвращает значения сэмплов по строке "имя{метки}" как она записана в выводе
+func scrapeMetrics(t *testing.T, c *http.Client, baseURL *url.URL) map[string]float64 {
+	t.Helper()
+
+	samples, err := readMetrics(c, baseURL)
+	require.NoError(t, err)
+
+	return samples
+}
+
+func TestMetrics(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL + "/a", srv.URL + "/b", srv.URL + "/missing", "http://exa\nmple.com/"},
+		Workers:   2,
+		TimeoutMS: 2000,
+	}
+
+	crawl(t, c, baseUrl, req)
+	crawl(t, c, baseUrl, req)
+
+	m := scrapeMetrics(t, c, baseUrl)
+
+	require.InDelta(t, 2, m[`crawler_http_requests_total{code="200",route="/crawl"}`], 0)
+	require.InDelta(t, 3, m["crawler_urls_fetched_total"], 0)
+	require.InDelta(t, 3, m["crawler_cache_misses_total"], 0)
+	require.InDelta(t, 3, m["crawler_cache_hits_total"], 0)
+
+	// результаты из кэша тоже считаются по коду ответа
+	require.InDelta(t, 4, m[`crawler_fetch_status_total{code="200"}`], 0)
+	require.InDelta(t, 2, m[`crawler_fetch_status_total{code="404"}`], 0)
+	require.InDelta(t, 2, m[`crawler_fetch_errors_total{error_code="unsafe_url"}`], 0)
+
+	require.InDelta(t, 3, m["crawler_fetch_duration_seconds_count"], 0)
+	require.Greater(t, m["crawler_fetch_duration_seconds_sum"], 0.02)
+	require.InDelta(t, 3, m[`crawler_fetch_duration_seconds_bucket{le="+Inf"}`], 0)
+
+	require.Zero(t, m["crawler_workers_busy"])
+	require.Zero(t, m["crawler_workers_capacity"])
+
+	// сам /metrics в счётчики запросов не попадает
+	for k := range m {
+		require.NotContains(t, k, `route="/metrics"`)
+	}
+}
+
+func TestMetricsRouteTemplates(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	for range 2 {
+		id := submitJob(t, c, baseUrl, CrawlRequest{
+			URLs:      makeURLs(t, srv.URL, 2),
+			Workers:   1,
+			TimeoutMS: 2000,
+		})
+
+		waitJob(t, c, baseUrl, id)
+	}
+
+	resp, err := c.Get(constructJobsPath(t, baseUrl, "unknown", "events").String())
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	m := scrapeMetrics(t, c, baseUrl)
+
+	require.InDelta(t, 2, m[`crawler_http_requests_total{code="202",route="/jobs"}`], 0)
+	require.InDelta(t, 2, m[`crawler_http_requests_total{code="200",route="/jobs/{id}/events"}`], 0)
+	require.InDelta(t, 1, m[`crawler_http_requests_total{code="404",route="/jobs/{id}/events"}`], 0)
+}
+
+func TestMetricsWorkerSaturation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	const workers = 4
+
+	done := crawlAsync(c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL, 2),
+		Workers:   workers,
+		TimeoutMS: 5000,
+	})
+
+	// количество воркеров уменьшается до количества уникальных урлов
+	require.Eventually(t, func() bool {
+		m, err := readMetrics(c, baseUrl)
+		return err == nil && m["crawler_workers_busy"] == 2 && m["crawler_workers_capacity"] == 2
+	}, 2*time.Second, 20*time.Millisecond)
+
+	close(release)
+	require.Len(t, awaitCrawl(t, done), 2)
+
+	m := scrapeMetrics(t, c, baseUrl)
+	require.Zero(t, m["crawler_workers_busy"])
+	require.Zero(t, m["crawler_workers_capacity"])
+}
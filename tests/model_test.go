@@ -541,7 +541,8 @@ func TestCrawlDedupSameURLConcurrentWithExtraNormalization(t *testing.T) {
 }
 
 func TestFetchWithCacheTTL(t *testing.T) {
-	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
 	t.Cleanup(stopWait)
 
 	p := constructCrawlPath(t, baseUrl).String()
@@ -573,7 +574,7 @@ func TestFetchWithCacheTTL(t *testing.T) {
 
 	// const cacheTTL = time.Second
 	for range k {
-		time.Sleep(time.Millisecond * 50)
+		clk.Advance(time.Millisecond * 50)
 
 		resp, err := c.Post(p, contentTypeJson, bytes.NewReader(reqBody))
 		require.NoError(t, err)
@@ -590,7 +591,7 @@ func TestFetchWithCacheTTL(t *testing.T) {
 
 	require.EqualValues(t, 1, hits.Load())
 
-	time.Sleep(cacheTTL + time.Millisecond*100)
+	clk.Advance(cacheTTL + time.Millisecond*100)
 
 	resp, err := c.Post(p, contentTypeJson, bytes.NewReader(reqBody))
 	require.NoError(t, err)
@@ -608,7 +609,8 @@ func TestFetchWithCacheTTL(t *testing.T) {
 }
 
 func TestFetchWithCacheTTLWithNormalization(t *testing.T) {
-	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
 	t.Cleanup(stopWait)
 
 	p := constructCrawlPath(t, baseUrl).String()
@@ -666,7 +668,7 @@ func TestFetchWithCacheTTLWithNormalization(t *testing.T) {
 
 	// const cacheTTL = time.Second
 	for range k / 3 {
-		time.Sleep(time.Millisecond * 50)
+		clk.Advance(time.Millisecond * 50)
 
 		resp, err := c.Post(p, contentTypeJson, bytes.NewReader(reqBody1))
 		require.NoError(t, err)
@@ -682,7 +684,7 @@ func TestFetchWithCacheTTLWithNormalization(t *testing.T) {
 	}
 
 	for range k / 3 {
-		time.Sleep(time.Millisecond * 50)
+		clk.Advance(time.Millisecond * 50)
 
 		resp, err := c.Post(p, contentTypeJson, bytes.NewReader(reqBody2))
 		require.NoError(t, err)
@@ -698,7 +700,7 @@ func TestFetchWithCacheTTLWithNormalization(t *testing.T) {
 	}
 
 	for range k / 3 {
-		time.Sleep(time.Millisecond * 50)
+		clk.Advance(time.Millisecond * 50)
 
 		resp, err := c.Post(p, contentTypeJson, bytes.NewReader(reqBody3))
 		require.NoError(t, err)
@@ -714,7 +716,7 @@ func TestFetchWithCacheTTLWithNormalization(t *testing.T) {
 	}
 
 	require.EqualValues(t, 1, hits.Load())
-	time.Sleep(cacheTTL + time.Millisecond*100)
+	clk.Advance(cacheTTL + time.Millisecond*100)
 
 	resp, err := c.Post(p, contentTypeJson, bytes.NewReader(reqBody1))
 	require.NoError(t, err)
@@ -732,7 +734,8 @@ func TestFetchWithCacheTTLWithNormalization(t *testing.T) {
 }
 
 func TestFetchWithCacheTTLWithExtraNormalization(t *testing.T) {
-	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
 	t.Cleanup(stopWait)
 
 	p := constructCrawlPath(t, baseUrl).String()
@@ -790,7 +793,7 @@ func TestFetchWithCacheTTLWithExtraNormalization(t *testing.T) {
 
 	// const cacheTTL = time.Second
 	for range k / 3 {
-		time.Sleep(time.Millisecond * 50)
+		clk.Advance(time.Millisecond * 50)
 
 		resp, err := c.Post(p, contentTypeJson, bytes.NewReader(reqBody1))
 		require.NoError(t, err)
@@ -806,7 +809,7 @@ func TestFetchWithCacheTTLWithExtraNormalization(t *testing.T) {
 	}
 
 	for range k / 3 {
-		time.Sleep(time.Millisecond * 50)
+		clk.Advance(time.Millisecond * 50)
 
 		resp, err := c.Post(p, contentTypeJson, bytes.NewReader(reqBody2))
 		require.NoError(t, err)
@@ -822,7 +825,7 @@ func TestFetchWithCacheTTLWithExtraNormalization(t *testing.T) {
 	}
 
 	for range k / 3 {
-		time.Sleep(time.Millisecond * 50)
+		clk.Advance(time.Millisecond * 50)
 
 		resp, err := c.Post(p, contentTypeJson, bytes.NewReader(reqBody3))
 		require.NoError(t, err)
@@ -838,7 +841,7 @@ func TestFetchWithCacheTTLWithExtraNormalization(t *testing.T) {
 	}
 
 	require.EqualValues(t, 1, hits.Load())
-	time.Sleep(cacheTTL + time.Millisecond*100)
+	clk.Advance(cacheTTL + time.Millisecond*100)
 
 	resp, err := c.Post(p, contentTypeJson, bytes.NewReader(reqBody1))
 	require.NoError(t, err)
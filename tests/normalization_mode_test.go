@@ -0,0 +1,119 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const normalizationModesEnv = "CRAWLER_NORMALIZATION_MODES"
+
+func TestNormalizeURLWithModes(t *testing.T) {
+	cases := []struct {
+		mode string
+		a, b string
+		same bool
+	}{
+		{mode: "standard", a: "http://example.com/a%2Fb?1=x&2=y", b: "http://example.com/a/b?2=y&1=x", same: true},
+		{mode: "strict", a: "http://example.com/a%2Fb", b: "http://example.com/a/b", same: false},
+		{mode: "strict", a: "http://example.com/?1=x&2=y", b: "http://example.com/?2=y&1=x", same: false},
+		{mode: "strict", a: "HTTP://Example.COM/%7euser/./x/../y", b: "http://example.com/~user/y", same: true},
+		{mode: "strict", a: "http://example.com/a%2fb", b: "http://example.com/a%2Fb", same: true},
+		{mode: "off", a: "http://example.com/a/../b", b: "http://example.com/b", same: false},
+		{mode: "off", a: "http://example.com/b", b: "http://example.com/b", same: true},
+	}
+
+	for _, tc := range cases {
+		a, err := normalizeURLWith(tc.a, tc.mode)
+		require.NoError(t, err)
+
+		b, err := normalizeURLWith(tc.b, tc.mode)
+		require.NoError(t, err)
+
+		if tc.same {
+			require.Equal(t, a, b, "%s: %q vs %q", tc.mode, tc.a, tc.b)
+		} else {
+			require.NotEqual(t, a, b, "%s: %q vs %q", tc.mode, tc.a, tc.b)
+		}
+
+		again, err := normalizeURLWith(a, tc.mode)
+		require.NoError(t, err)
+		require.Equal(t, a, again, "%s is not idempotent for %q", tc.mode, tc.a)
+	}
+
+	for _, raw := range []string{"http://example.com:abc", "http://exa\nmple.com/"} {
+		for _, mode := range []string{"standard", "strict", "off"} {
+			_, err := normalizeURLWith(raw, mode)
+			require.Error(t, err, "%s: %q", mode, raw)
+		}
+	}
+
+	std, err := normalizeURLWith("http://example.com/a%2Fb?2=y&1=x", "standard")
+	require.NoError(t, err)
+
+	def, err := normalizeURL("http://example.com/a%2Fb?2=y&1=x")
+	require.NoError(t, err)
+	require.Equal(t, def, std)
+}
+
+func TestCrawlNormalizationStrict(t *testing.T) {
+	t.Setenv(normalizationModesEnv, "")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	urls := []string{
+		srv.URL + "/a%2Fb?1=hello&2=ohhh",
+		srv.URL + "/a/b?2=ohhh&1=hello",
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:          urls,
+		Workers:       2,
+		TimeoutMS:     2000,
+		Normalization: "strict",
+	})
+
+	require.Len(t, got, len(urls))
+	require.EqualValues(t, 2, hits.Load())
+}
+
+func TestCrawlNormalizationPolicy(t *testing.T) {
+	t.Setenv(normalizationModesEnv, "standard,strict")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for mode, want := range map[string]int{
+		"standard": http.StatusOK,
+		"strict":   http.StatusOK,
+		"off":      http.StatusBadRequest,
+		"lenient":  http.StatusBadRequest,
+	} {
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{
+			URLs:          []string{},
+			Workers:       1,
+			TimeoutMS:     1000,
+			Normalization: mode,
+		})
+
+		require.Equal(t, want, resp.StatusCode, mode)
+	}
+}
@@ -0,0 +1,68 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeCacheMatchesNormalizeURL(t *testing.T) {
+	c := newNormalizeCache(normalizeCacheSize)
+
+	for _, raw := range []string{
+		"http://example.com/a/b/../c",
+		"http://example.com:80/a%2Fb?2=ohhh&1=hello",
+		"http://example.com:abc",
+		"://example.com",
+	} {
+		expected, expectedErr := normalizeURL(raw)
+
+		for range 2 {
+			got, err := c.normalize(raw)
+
+			require.Equal(t, expected, got)
+			require.Equal(t, expectedErr, err)
+		}
+	}
+
+	require.Equal(t, 4, c.len())
+}
+
+func TestNormalizeCacheBounded(t *testing.T) {
+	const size = 3
+	c := newNormalizeCache(size)
+
+	raw := func(i int) string {
+		return "http://example.com/" + strconv.Itoa(i)
+	}
+
+	for i := range size {
+		_, err := c.normalize(raw(i))
+		require.NoError(t, err)
+	}
+
+	// обращение к самой старой записи делает её самой свежей
+	_, err := c.normalize(raw(0))
+	require.NoError(t, err)
+
+	_, err = c.normalize(raw(size))
+	require.NoError(t, err)
+
+	require.Equal(t, size, c.len())
+	require.True(t, c.contains(raw(0)))
+	require.False(t, c.contains(raw(1)))
+	require.True(t, c.contains(raw(2)))
+	require.True(t, c.contains(raw(size)))
+
+	// contains не влияет на порядок вытеснения
+	require.True(t, c.contains(raw(2)))
+
+	_, err = c.normalize(raw(size + 1))
+	require.NoError(t, err)
+
+	require.False(t, c.contains(raw(2)))
+	require.Equal(t, size, c.len())
+}
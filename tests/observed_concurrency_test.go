@@ -0,0 +1,122 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	peakConcurrencyHeader   = "X-Peak-Concurrency"
+	workerUtilizationHeader = "X-Worker-Utilization"
+)
+
+type observedWorkers struct {
+	Requested   int     `json:"requested"`
+	Effective   int     `json:"effective"`
+	Peak        int     `json:"peak"`
+	Utilization float64 `json:"utilization"`
+}
+
+func jobWorkers(t *testing.T, events []sseEvent) observedWorkers {
+	t.Helper()
+
+	require.NotEmpty(t, events)
+	require.Equal(t, "done", events[len(events)-1].Name)
+
+	var done struct {
+		Workers observedWorkers `json:"workers"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(events[len(events)-1].Data), &done))
+	return done.Workers
+}
+
+func TestJobObservedConcurrency(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv, _ := newConcurrencyTrackingServer(t, 50*time.Millisecond)
+	t.Cleanup(srv.Close)
+
+	const (
+		workers = 4
+		n       = 16
+	)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL+"/free", n),
+		Workers:   workers,
+		TimeoutMS: 5000,
+	})
+
+	free := jobWorkers(t, waitJob(t, c, baseUrl, id))
+	require.Equal(t, workers, free.Requested)
+	require.Equal(t, workers, free.Effective)
+	require.Equal(t, workers, free.Peak)
+	require.Greater(t, free.Utilization, 0.7)
+	require.LessOrEqual(t, free.Utilization, 1.0)
+
+	// один хост и лимит 1: воркеры есть, но упираются в вежливость
+	id = submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:                 makeURLs(t, srv.URL+"/polite", n/2),
+		Workers:              workers,
+		TimeoutMS:            5000,
+		MaxConcurrentPerHost: 1,
+	})
+
+	polite := jobWorkers(t, waitJob(t, c, baseUrl, id))
+	require.Equal(t, workers, polite.Effective)
+	require.Equal(t, 1, polite.Peak)
+	require.Greater(t, polite.Utilization, 0.0)
+	require.Less(t, polite.Utilization, 0.5)
+
+	// повтор из кэша не даёт сетевых запросов
+	id = submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL+"/free", n),
+		Workers:   workers,
+		TimeoutMS: 5000,
+	})
+
+	cached := jobWorkers(t, waitJob(t, c, baseUrl, id))
+	require.Zero(t, cached.Peak)
+}
+
+func TestCrawlObservedConcurrencyTrailers(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv, peak := newConcurrencyTrackingServer(t, 50*time.Millisecond)
+	t.Cleanup(srv.Close)
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL, 6),
+		Workers:   3,
+		TimeoutMS: 5000,
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Trailer, peakConcurrencyHeader)
+	require.Contains(t, resp.Trailer, workerUtilizationHeader)
+
+	_, err := io.Copy(io.Discard, resp.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, strconv.FormatInt(peak.Load(), 10), resp.Trailer.Get(peakConcurrencyHeader))
+
+	utilization, err := strconv.ParseFloat(resp.Trailer.Get(workerUtilizationHeader), 64)
+	require.NoError(t, err)
+	require.Greater(t, utilization, 0.5)
+	require.LessOrEqual(t, utilization, 1.0)
+}
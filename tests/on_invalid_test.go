@@ -0,0 +1,235 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	errorCodeEmptyURL = "empty_url"
+	skippedURLsHeader = "X-Skipped-URLs"
+)
+
+func TestOnInvalidReport(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	urls := []string{"", srv.URL + "/200", "  \t", "", "http://[::1"}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, len(urls))
+
+	for _, i := range []int{0, 2, 3} {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Equal(t, errorCodeEmptyURL, got[i].ErrorCode)
+		require.NotEmpty(t, got[i].Error)
+	}
+
+	require.Equal(t, http.StatusOK, got[1].StatusCode)
+	require.Equal(t, errorCodeInvalidURL, got[4].ErrorCode)
+}
+
+func TestOnInvalidSkip(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	urls := []string{srv.URL + "/200", " ", srv.URL + "/404", "", "http://[::1"}
+	req := CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+		OnInvalid: "skip",
+	}
+
+	resp := postCrawl(t, c, baseUrl, req)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "2", resp.Header.Get(skippedURLsHeader))
+
+	var got []CrawlResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Len(t, got, 3)
+
+	require.Equal(t, urls[0], got[0].URL)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Equal(t, urls[2], got[1].URL)
+	require.Equal(t, http.StatusNotFound, got[1].StatusCode)
+	require.Equal(t, urls[4], got[2].URL)
+	require.Equal(t, errorCodeInvalidURL, got[2].ErrorCode)
+
+	events := waitJob(t, c, baseUrl, submitJob(t, c, baseUrl, req))
+	require.NotEmpty(t, events)
+
+	var indexes []int
+	for _, e := range events {
+		if e.Name != "result" {
+			continue
+		}
+
+		var r sseResult
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &r))
+		require.Equal(t, urls[r.Index], r.URL)
+
+		indexes = append(indexes, r.Index)
+	}
+
+	require.ElementsMatch(t, []int{0, 2, 4}, indexes)
+
+	var done struct {
+		Skipped []int `json:"skipped"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(events[len(events)-1].Data), &done))
+	require.Equal(t, []int{1, 3}, done.Skipped)
+}
+
+func TestOnInvalidReject(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"", srv.URL + "/200", "http://example.com:abc", "\n"},
+		Workers:   2,
+		TimeoutMS: 2000,
+		OnInvalid: "reject",
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var body struct {
+		Error   string `json:"error"`
+		Invalid []int  `json:"invalid"`
+	}
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.NotEmpty(t, body.Error)
+	require.Equal(t, []int{0, 2, 3}, body.Invalid)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/204"},
+		Workers:   1,
+		TimeoutMS: 2000,
+		OnInvalid: "reject",
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+
+	resp = postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/204"},
+		Workers:   1,
+		TimeoutMS: 2000,
+		OnInvalid: "ignore",
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestOnInvalidReportAllInvalid(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	urls := []string{"http://[::1", "http://example.com:abc", ""}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   1,
+		TimeoutMS: 1000,
+	})
+
+	require.Len(t, got, len(urls))
+	for i, r := range got {
+		require.Equal(t, urls[i], r.URL)
+		require.NotEmpty(t, r.ErrorCode, r.URL)
+		require.False(t, r.Success, r.URL)
+	}
+
+	require.Contains(t, got[0].Error, "missing ']' in host")
+	require.Contains(t, got[1].Error, "invalid port")
+}
+
+func TestOnInvalidSources(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/list.txt" {
+			fmt.Fprintf(w, "%[1]s/a\nhttp://[::1\n%[1]s/b\nhttp://example.com:abc\n", srv.URL)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL + "/inline"},
+		URLLists:  []string{srv.URL + "/list.txt"},
+		Workers:   2,
+		TimeoutMS: 2000,
+	}
+
+	got := crawl(t, c, baseUrl, req)
+	require.Len(t, got, 5)
+
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+	require.Equal(t, http.StatusNoContent, got[1].StatusCode)
+	require.Equal(t, "http://[::1", got[2].URL)
+	require.Equal(t, errorCodeInvalidURL, got[2].ErrorCode)
+	require.Equal(t, http.StatusNoContent, got[3].StatusCode)
+	require.Equal(t, "http://example.com:abc", got[4].URL)
+	require.Equal(t, errorCodeInvalidURL, got[4].ErrorCode)
+
+	events := waitJob(t, c, baseUrl, submitJob(t, c, baseUrl, req))
+	require.NotEmpty(t, events)
+
+	var done struct {
+		Sources []struct {
+			Kind    string `json:"kind"`
+			URLs    int    `json:"urls"`
+			Invalid int    `json:"invalid"`
+		} `json:"sources"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(events[len(events)-1].Data), &done))
+	require.Len(t, done.Sources, 2)
+	require.Equal(t, "url_list", done.Sources[1].Kind)
+	require.Equal(t, 2, done.Sources[1].Invalid)
+
+	for _, mode := range []string{"skip", "reject"} {
+		req.OnInvalid = mode
+
+		got = crawl(t, c, baseUrl, req)
+		require.Len(t, got, 3, mode)
+
+		for _, r := range got {
+			require.Equal(t, http.StatusNoContent, r.StatusCode, mode)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const modeOptions = "options"
+
+func TestCrawlOptionsMode(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var gets, options atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions {
+			gets.Add(1)
+			w.WriteHeader(http.StatusOK)
+
+			return
+		}
+
+		options.Add(1)
+
+		switch r.URL.Path {
+		case "/users":
+			w.Header().Set("Allow", "get, POST,HEAD")
+		case "/split":
+			w.Header().Add("Allow", "GET, PUT")
+			w.Header().Add("Allow", "put, DELETE")
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	urls := []string{
+		srv.URL + "/users",
+		srv.URL + "/split",
+		srv.URL + "/none",
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   len(urls),
+		TimeoutMS: 2000,
+		Mode:      modeOptions,
+	})
+
+	require.Len(t, got, len(urls))
+	for i := range urls {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Empty(t, got[i].Error)
+		require.Equal(t, http.StatusNoContent, got[i].StatusCode)
+	}
+
+	require.Equal(t, []string{http.MethodGet, http.MethodPost, http.MethodHead}, got[0].Allow)
+	require.Equal(t, []string{http.MethodGet, http.MethodPut, http.MethodDelete}, got[1].Allow)
+	require.Empty(t, got[2].Allow)
+
+	require.EqualValues(t, len(urls), options.Load())
+	require.Zero(t, gets.Load())
+
+	// hint: режим - часть ключа кэша
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls[:1],
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Empty(t, got[0].Allow)
+	require.EqualValues(t, 1, gets.Load())
+}
+
+func TestCrawlUnknownMode(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://example.com"},
+		Workers:   1,
+		TimeoutMS: 1000,
+		Mode:      "trace",
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,224 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingTransport считает запросы и помечает их заголовком
+type countingTransport struct {
+	calls atomic.Int64
+	mark  string
+}
+
+func (rt *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	rt.calls.Add(1)
+
+	r = r.Clone(r.Context())
+	r.Header.Set("X-Test-Transport", rt.mark)
+
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// newMarkServer отвечает 200, если запрос пришёл через транспорт с меткой, иначе 418
+func newMarkServer(t *testing.T, mark string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Test-Transport") != mark {
+			w.WriteHeader(http.StatusTeapot)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOptionsTransport(t *testing.T) {
+	c := client()
+
+	clientRT := &countingTransport{mark: "client"}
+	rt := &countingTransport{mark: "transport"}
+
+	t.Run("http client", func(t *testing.T) {
+		hc := &http.Client{Transport: clientRT}
+
+		baseUrl, stopWait := serveCrawler(t.Context(), t, New(WithHTTPClient(hc)))
+		t.Cleanup(stopWait)
+
+		srv := newMarkServer(t, "client")
+		urls := makeURLs(t, srv.URL, 3)
+
+		got := crawl(t, c, baseUrl, CrawlRequest{URLs: urls, Workers: 2, TimeoutMS: 2000})
+
+		require.Len(t, got, len(urls))
+		for _, r := range got {
+			require.Equal(t, http.StatusOK, r.StatusCode, r.URL)
+		}
+
+		require.GreaterOrEqual(t, clientRT.calls.Load(), int64(len(urls)))
+		require.Nil(t, hc.CheckRedirect, "the passed client must not be modified")
+	})
+
+	t.Run("transport overrides client", func(t *testing.T) {
+		before := clientRT.calls.Load()
+
+		hc := &http.Client{Transport: clientRT}
+
+		baseUrl, stopWait := serveCrawler(t.Context(), t, New(WithHTTPClient(hc), WithTransport(rt)))
+		t.Cleanup(stopWait)
+
+		srv := newMarkServer(t, "transport")
+
+		got := crawl(t, c, baseUrl, CrawlRequest{URLs: []string{srv.URL + "/"}, Workers: 1, TimeoutMS: 2000})
+
+		require.Len(t, got, 1)
+		require.Equal(t, http.StatusOK, got[0].StatusCode)
+		require.Positive(t, rt.calls.Load())
+		require.Equal(t, before, clientRT.calls.Load())
+
+		// собственный пул соединений требует *http.Transport
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{
+			URLs:              []string{srv.URL + "/"},
+			Workers:           1,
+			TimeoutMS:         2000,
+			IsolatedTransport: true,
+		})
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestOptionsMaxWorkers(t *testing.T) {
+	baseUrl, stopWait := serveCrawler(t.Context(), t, New(WithMaxWorkers(2)))
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := newStatusServer(t)
+	urls := makeURLs(t, srv.URL, 4)
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{URLs: urls, Workers: 3, TimeoutMS: 2000})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = postCrawl(t, c, baseUrl, CrawlRequest{URLs: urls, Workers: 2, TimeoutMS: 2000})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "2", resp.Header.Get("X-Effective-Workers"))
+}
+
+func TestOptionsMaxWorkersDefaultWorkersEnv(t *testing.T) {
+	t.Setenv(defaultWorkersEnv, "8")
+
+	requireStartError(t, New(WithMaxWorkers(4)))
+}
+
+func TestOptionsCacheTTL(t *testing.T) {
+	clk := newFakeClock()
+
+	baseUrl, stopWait := serveCrawler(t.Context(), t, newCrawler(clk, WithCacheTTL(3*cacheTTL)))
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	req := CrawlRequest{URLs: []string{srv.URL + "/"}, Workers: 1, TimeoutMS: 2000}
+
+	crawl(t, c, baseUrl, req)
+	require.EqualValues(t, 1, hits.Load())
+
+	// стандартный TTL уже истёк бы, а заданный - нет
+	clk.Advance(2 * cacheTTL)
+	crawl(t, c, baseUrl, req)
+	require.EqualValues(t, 1, hits.Load())
+
+	clk.Advance(2 * cacheTTL)
+	crawl(t, c, baseUrl, req)
+	require.EqualValues(t, 2, hits.Load())
+}
+
+func TestOptionsLogger(t *testing.T) {
+	global := captureLogs(t)
+
+	var buf syncBuffer
+	l := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	baseUrl, stopWait := serveCrawler(t.Context(), t, New(WithLogger(l)))
+	t.Cleanup(stopWait)
+
+	srv := newStatusServer(t)
+
+	crawl(t, client(), baseUrl, CrawlRequest{URLs: []string{srv.URL + "/"}, Workers: 1, TimeoutMS: 2000})
+
+	require.Eventually(t, func() bool {
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var entry map[string]any
+			if json.Unmarshal([]byte(line), &entry) == nil && entry["msg"] == "request finished" {
+				return entry["request_id"] != nil
+			}
+		}
+
+		return false
+	}, time.Second, 10*time.Millisecond)
+
+	for _, line := range global() {
+		require.NotEqual(t, "request finished", line["msg"], "instance logs must not go to the package logger")
+	}
+}
+
+func TestOptionsDefaultTimeout(t *testing.T) {
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	t.Run("without option", func(t *testing.T) {
+		baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+		t.Cleanup(stopWait)
+
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{URLs: []string{srv.URL + "/"}, Workers: 1})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("with option", func(t *testing.T) {
+		baseUrl, stopWait := serveCrawler(t.Context(), t, New(WithDefaultTimeout(200*time.Millisecond)))
+		t.Cleanup(stopWait)
+
+		start := time.Now()
+		got := crawl(t, c, baseUrl, CrawlRequest{URLs: []string{srv.URL + "/"}, Workers: 1})
+
+		require.Len(t, got, 1)
+		require.Equal(t, errorCodeTimeout, got[0].ErrorCode)
+		require.Less(t, time.Since(start), 1500*time.Millisecond)
+
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{URLs: []string{srv.URL + "/"}, Workers: 1, TimeoutMS: -1})
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -5,8 +5,12 @@ package crawler
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"runtime"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -80,3 +84,267 @@ func TestCrawlerSleepPerformance(t *testing.T) {
 
 	require.LessOrEqual(t, delay, time.Second*6)
 }
+
+func newNoContentServer(tb testing.TB) *httptest.Server {
+	tb.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tb.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCrawlHighThroughput(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newNoContentServer(t)
+
+	const (
+		n          = 20_000
+		minURLsSec = 10_000
+	)
+
+	urls := makeURLs(t, srv.URL, n)
+
+	start := time.Now()
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:           urls,
+		Workers:        64,
+		TimeoutMS:      30_000,
+		HighThroughput: true,
+	})
+	delay := time.Since(start)
+
+	require.Len(t, got, n)
+	for i := range urls {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Empty(t, got[i].Error)
+		require.Equal(t, http.StatusNoContent, got[i].StatusCode)
+	}
+
+	require.GreaterOrEqual(t, float64(n)/delay.Seconds(), float64(minURLsSec))
+}
+
+func BenchmarkCrawlHighThroughput(b *testing.B) {
+	baseUrl, stopWait := startCrawlerServer(b.Context(), b)
+	b.Cleanup(stopWait)
+
+	c := client()
+	srv := newNoContentServer(b)
+	p := constructCrawlPath(b, baseUrl).String()
+
+	const n = 1000
+
+	bodies := make([][]byte, b.N)
+	for i := range bodies {
+		// уникальные урлы на каждой итерации, чтобы не попадать в кэш
+		reqBody, err := json.Marshal(CrawlRequest{
+			URLs:           makeURLs(b, srv.URL+"/"+strconv.Itoa(i), n),
+			Workers:        64,
+			TimeoutMS:      30_000,
+			HighThroughput: true,
+		})
+		require.NoError(b, err)
+
+		bodies[i] = reqBody
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := range b.N {
+		resp, err := c.Post(p, contentTypeJson, bytes.NewReader(bodies[i]))
+		require.NoError(b, err)
+
+		_, err = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		require.NoError(b, err)
+		require.Equal(b, http.StatusOK, resp.StatusCode)
+	}
+
+	b.ReportMetric(float64(b.N*n)/b.Elapsed().Seconds(), "urls/s")
+}
+
+func BenchmarkNormalizeURL(b *testing.B) {
+	const raw = "http://example.com:80/a/b/../c/%2Fd?z=1&y=2&x=3"
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for range b.N {
+			_, _ = normalizeURL(raw)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		c := newNormalizeCache(normalizeCacheSize)
+		b.ReportAllocs()
+
+		for range b.N {
+			_, _ = c.normalize(raw)
+		}
+	})
+}
+
+func TestCrawlMixedHostsRebalance(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	newDelayServer := func(delay time.Duration) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		t.Cleanup(srv.Close)
+		return srv
+	}
+
+	slow := newDelayServer(300 * time.Millisecond)
+	fast := newDelayServer(20 * time.Millisecond)
+
+	const (
+		slowN = 6
+		fastN = 200
+		limit = 2
+	)
+
+	// медленный хост первым: без перераспределения все воркеры упрутся в его лимит
+	urls := append(makeURLs(t, slow.URL, slowN), makeURLs(t, fast.URL, fastN)...)
+
+	start := time.Now()
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:                 urls,
+		Workers:              2 * limit,
+		TimeoutMS:            30_000,
+		MaxConcurrentPerHost: limit,
+	})
+	delay := time.Since(start)
+
+	require.Len(t, got, len(urls))
+	for i := range urls {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Empty(t, got[i].Error)
+		require.Equal(t, http.StatusNoContent, got[i].StatusCode)
+	}
+
+	// последовательно: 6/2*300ms + 200/2*20ms = 2.9s, параллельно по хостам: max(0.9s, 2s)
+	require.Less(t, delay, 2500*time.Millisecond)
+}
+
+// peakHeapGrowth возвращает пиковый прирост HeapInuse во время f относительно кучи сразу после GC.
+// Мусор предыдущих тестов собирается до замера, поэтому результат не зависит от порядка тестов
+func peakHeapGrowth(f func()) int64 {
+	runtime.GC()
+
+	var base runtime.MemStats
+	runtime.ReadMemStats(&base)
+
+	var (
+		peak = base.HeapInuse
+		stop = make(chan struct{})
+		done = make(chan struct{})
+	)
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				var m runtime.MemStats
+				runtime.ReadMemStats(&m)
+
+				peak = max(peak, m.HeapInuse)
+			}
+		}
+	}()
+
+	f()
+
+	close(stop)
+	<-done
+
+	return int64(peak) - int64(base.HeapInuse)
+}
+
+// TestCrawlLargeBodyStreaming проверяет, что при записи тел на диск память не растёт вместе с размером тела
+func TestCrawlLargeBodyStreaming(t *testing.T) {
+	const (
+		bodyDirEnv = "CRAWLER_BODY_DIR"
+		smallSize  = 1 << 20
+		bodySize   = 128 << 20
+		chunkSize  = 32 << 10
+		heapBudget = 32 << 20
+	)
+
+	dir := t.TempDir()
+	t.Setenv(bodyDirEnv, dir)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := smallSize
+		if r.URL.Path == "/large" {
+			size = bodySize
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(size))
+
+		chunk := bytes.Repeat([]byte("x"), chunkSize)
+		for written := 0; written < size; written += chunkSize {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	crawlOne := func(path string) func() {
+		return func() {
+			got := crawl(t, c, baseUrl, CrawlRequest{
+				URLs:      []string{srv.URL + path},
+				Workers:   1,
+				TimeoutMS: 60_000,
+			})
+
+			require.Len(t, got, 1)
+			require.Equal(t, http.StatusOK, got[0].StatusCode)
+		}
+	}
+
+	// контрольный обход прогревает пулы и буферы сервера, его прирост - фон, а не тело
+	control := peakHeapGrowth(crawlOne("/small"))
+	growth := peakHeapGrowth(crawlOne("/large"))
+
+	require.Less(t, growth-control, int64(heapBudget), "heap grew by %d bytes, control %d", growth, control)
+
+	var stored int64
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	for _, e := range entries {
+		info, err := e.Info()
+		require.NoError(t, err)
+
+		stored = max(stored, info.Size())
+	}
+
+	require.EqualValues(t, bodySize, stored)
+}
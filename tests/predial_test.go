@@ -0,0 +1,273 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawlPredial(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var (
+		mu        sync.Mutex
+		slowStart time.Time
+		fastDial  time.Time
+		conns     atomic.Int64
+	)
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if slowStart.IsZero() {
+			slowStart = time.Now()
+		}
+		mu.Unlock()
+
+		time.Sleep(300 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(slow.Close)
+
+	fast := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	fast.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state != http.StateNew {
+			return
+		}
+
+		if conns.Add(1) == 1 {
+			mu.Lock()
+			fastDial = time.Now()
+			mu.Unlock()
+		}
+	}
+
+	fast.Start()
+	t.Cleanup(fast.Close)
+
+	urls := []string{
+		slow.URL + "/item-0",
+		fast.URL + "/item-0",
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   1,
+		TimeoutMS: 3000,
+		Predial:   true,
+	})
+
+	require.Len(t, got, len(urls))
+	for i := range urls {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Empty(t, got[i].Error)
+		require.Equal(t, http.StatusNoContent, got[i].StatusCode)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.False(t, fastDial.IsZero())
+	require.True(t, fastDial.Before(slowStart),
+		"expected connection to the second host to be established before the first fetch was dispatched")
+
+	require.EqualValues(t, 1, conns.Load(), "expected predialed connection to be reused")
+}
+
+func TestCrawlPredialTLS(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		firstFetch time.Time
+		handshake  time.Time
+		handshakes atomic.Int64
+	)
+
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		if firstFetch.IsZero() {
+			firstFetch = time.Now()
+		}
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(plain.Close)
+
+	secure := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	secure.TLS = &tls.Config{
+		VerifyConnection: func(tls.ConnectionState) error {
+			if handshakes.Add(1) == 1 {
+				mu.Lock()
+				handshake = time.Now()
+				mu.Unlock()
+			}
+
+			return nil
+		},
+	}
+
+	secure.StartTLS()
+	t.Cleanup(secure.Close)
+
+	trustServer(t, secure)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	urls := []string{
+		plain.URL + "/item-0",
+		secure.URL + "/item-0",
+		secure.URL + "/item-1",
+	}
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   1,
+		TimeoutMS: 3000,
+		Predial:   true,
+	})
+
+	require.Len(t, got, len(urls))
+	for i := range urls {
+		require.Empty(t, got[i].Error)
+		require.Equal(t, http.StatusNoContent, got[i].StatusCode)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.False(t, handshake.IsZero())
+	require.True(t, handshake.Before(firstFetch),
+		"expected TLS handshake to complete before the first fetch was dispatched")
+
+	require.EqualValues(t, 1, handshakes.Load(), "expected predialed TLS connection to be reused")
+}
+
+func TestCrawlPredialBounded(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	// принимает TCP-соединения, но не отвечает на TLS handshake
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var (
+		connsMu sync.Mutex
+		conns   []net.Conn
+	)
+
+	t.Cleanup(func() {
+		ln.Close()
+
+		connsMu.Lock()
+		defer connsMu.Unlock()
+
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			connsMu.Lock()
+			conns = append(conns, conn)
+			connsMu.Unlock()
+		}
+	}()
+
+	var (
+		mu      sync.Mutex
+		fetched time.Time
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched = time.Now()
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	start := time.Now()
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:      []string{"https://" + ln.Addr().String() + "/item-0", srv.URL + "/item-0"},
+		Workers:   2,
+		TimeoutMS: 3000,
+		Predial:   true,
+	})
+
+	require.Len(t, got, 2)
+	require.NotEmpty(t, got[0].Error)
+	require.Equal(t, http.StatusNoContent, got[1].StatusCode)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Less(t, fetched.Sub(start), predialTimeout+500*time.Millisecond,
+		"hanging predial must not hold workers longer than predialTimeout")
+}
+
+func TestCrawlPredialUnreachableHost(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	urls := []string{
+		closed.URL + "/item-0",
+		srv.URL + "/item-0",
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 3000,
+		Predial:   true,
+	})
+
+	require.Len(t, got, len(urls))
+
+	require.Equal(t, urls[0], got[0].URL)
+	require.NotEmpty(t, got[0].Error)
+	require.Zero(t, got[0].StatusCode)
+
+	require.Equal(t, urls[1], got[1].URL)
+	require.Empty(t, got[1].Error)
+	require.Equal(t, http.StatusOK, got[1].StatusCode)
+}
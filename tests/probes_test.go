@@ -0,0 +1,143 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	probeHostsEnv                = "CRAWLER_PROBE_HOSTS"
+	errorCodeProbeHostNotAllowed = "probe_host_not_allowed"
+)
+
+type recordedProbe struct {
+	Method      string
+	Body        string
+	ContentType string
+}
+
+func TestCrawlProbes(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		recorded []recordedProbe
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// обработчик работает не в горутине теста: ошибка чтения видна по отсутствию записи и коду 500
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		mu.Lock()
+		recorded = append(recorded, recordedProbe{
+			Method:      r.Method,
+			Body:        string(body),
+			ContentType: r.Header.Get("Content-Type"),
+		})
+		mu.Unlock()
+
+		if r.Method == http.MethodPost && strings.Contains(string(body), "__typename") {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	denied := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request to a host outside %s", probeHostsEnv)
+	}))
+
+	t.Cleanup(denied.Close)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	t.Setenv(probeHostsEnv, "example.com,"+u.Host)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	const graphqlHealth = `{"query":"{ __typename }"}`
+
+	probes := []Probe{
+		{URL: srv.URL + "/graphql", Method: http.MethodPost, Body: graphqlHealth},
+		{URL: srv.URL + "/graphql", Method: http.MethodPost, Body: graphqlHealth},
+		{URL: srv.URL + "/hook", Method: http.MethodPut, Body: "a=1", ContentType: "application/x-www-form-urlencoded"},
+		{URL: denied.URL + "/hook", Method: http.MethodPost, Body: "{}"},
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/plain"},
+		Workers:   2,
+		TimeoutMS: 2000,
+		Probes:    probes,
+	})
+
+	require.Len(t, got, 1+len(probes))
+
+	require.Equal(t, srv.URL+"/plain", got[0].URL)
+	require.Equal(t, http.StatusAccepted, got[0].StatusCode)
+
+	for i, p := range probes[:3] {
+		require.Equal(t, p.URL, got[1+i].URL)
+		require.Empty(t, got[1+i].Error)
+	}
+
+	require.Equal(t, http.StatusOK, got[1].StatusCode)
+	require.Equal(t, http.StatusOK, got[2].StatusCode)
+	require.Equal(t, http.StatusAccepted, got[3].StatusCode)
+
+	require.Equal(t, denied.URL+"/hook", got[4].URL)
+	require.Equal(t, errorCodeProbeHostNotAllowed, got[4].ErrorCode)
+	require.Zero(t, got[4].StatusCode)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// одинаковые probes не схлопываются кэшем и singleflight
+	require.ElementsMatch(t, []recordedProbe{
+		{Method: http.MethodGet},
+		{Method: http.MethodPost, Body: graphqlHealth, ContentType: contentTypeJson},
+		{Method: http.MethodPost, Body: graphqlHealth, ContentType: contentTypeJson},
+		{Method: http.MethodPut, Body: "a=1", ContentType: "application/x-www-form-urlencoded"},
+	}, recorded)
+}
+
+func TestCrawlProbesValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, p := range []Probe{
+		{URL: "http://example.com", Method: http.MethodGet},
+		{URL: "http://example.com", Method: "CONNECT"},
+		{URL: "http://example.com", Method: ""},
+		{URL: "http://example.com", Method: http.MethodPost, Body: strings.Repeat("x", maxProbeBodySize+1)},
+	} {
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{},
+			Workers:   1,
+			TimeoutMS: 1000,
+			Probes:    []Probe{p},
+		})
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "method %q, body %d bytes", p.Method, len(p.Body))
+	}
+}
@@ -0,0 +1,119 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var pprofProfiles = []string{"cpu", "allocs", "allocs_base"}
+
+func getJobProfile(t *testing.T, c *http.Client, baseURL *url.URL, id, profile string) (int, []byte) {
+	t.Helper()
+
+	resp, err := c.Get(constructJobsPath(t, baseURL, id, "profile", profile).String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp.StatusCode, body
+}
+
+func TestJobProfile(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, inFlight, release := newGateServer(t)
+
+	req := CrawlRequest{
+		URLs:      makeURLs(t, srv.URL+"/profile", 4),
+		Workers:   2,
+		TimeoutMS: 5000,
+		Profile:   true,
+	}
+
+	id := submitJob(t, c, baseUrl, req)
+
+	status, _ := getJobProfile(t, c, baseUrl, id, "cpu")
+	require.Equal(t, http.StatusConflict, status)
+
+	// CPU-профиль в процессе один
+	resp := postWithKey(t, c, constructJobsPath(t, baseUrl), "", req)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// профиль должен застать воркеров за обходом
+	require.Eventually(t, func() bool {
+		return inFlight("profile") == req.Workers
+	}, time.Second, 10*time.Millisecond)
+
+	release()
+	waitJob(t, c, baseUrl, id)
+
+	for _, profile := range pprofProfiles {
+		status, body := getJobProfile(t, c, baseUrl, id, profile)
+		require.Equal(t, http.StatusOK, status, profile)
+
+		// pprof - gzip-сжатый protobuf
+		require.Greater(t, len(body), 2, profile)
+		require.Equal(t, []byte{0x1f, 0x8b}, body[:2], profile)
+	}
+
+	status, _ = getJobProfile(t, c, baseUrl, id, "goroutine")
+	require.Equal(t, http.StatusNotFound, status)
+
+	// после завершения можно снова профилировать
+	next := submitJob(t, c, baseUrl, req)
+	waitJob(t, c, baseUrl, next)
+
+	status, _ = getJobProfile(t, c, baseUrl, next, "cpu")
+	require.Equal(t, http.StatusOK, status)
+}
+
+func TestJobProfileNotRequested(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/200"},
+		Workers:   1,
+		TimeoutMS: 1000,
+	})
+
+	waitJob(t, c, baseUrl, id)
+
+	for _, profile := range pprofProfiles {
+		status, _ := getJobProfile(t, c, baseUrl, id, profile)
+		require.Equal(t, http.StatusNotFound, status, profile)
+	}
+
+	status, _ := getJobProfile(t, c, baseUrl, "unknown", "cpu")
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestCrawlProfileRejected(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://127.0.0.1/"},
+		Workers:   1,
+		TimeoutMS: 1000,
+		Profile:   true,
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,197 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawlDetectProvider(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	headers := map[string]map[string]string{
+		"/cf-ray":         {"CF-Ray": "8c1e2f3a4b5c6d7e-AMS"},
+		"/cf-server":      {"Server": "Cloudflare"},
+		"/fastly":         {"X-Served-By": "cache-ams21000-AMS"},
+		"/varnish":        {"Via": "1.1 Varnish"},
+		"/cloudfront-id":  {"X-Amz-Cf-Id": "abc=="},
+		"/cloudfront-via": {"Via": "1.1 1234.cloudfront.net (CloudFront)"},
+		"/akamai":         {"Server": "AkamaiGHost"},
+		"/first-wins":     {"CF-Ray": "8c1e2f3a4b5c6d7e-AMS", "X-Served-By": "cache-ams21000-AMS"},
+		"/unknown":        {"Server": "nginx"},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range headers[r.URL.Path] {
+			w.Header().Set(k, v)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	expected := []struct {
+		path     string
+		provider string
+	}{
+		{path: "/cf-ray", provider: "cloudflare"},
+		{path: "/cf-server", provider: "cloudflare"},
+		{path: "/fastly", provider: "fastly"},
+		{path: "/varnish", provider: "fastly"},
+		{path: "/cloudfront-id", provider: "cloudfront"},
+		{path: "/cloudfront-via", provider: "cloudfront"},
+		{path: "/akamai", provider: "akamai"},
+		{path: "/first-wins", provider: "cloudflare"},
+		{path: "/unknown", provider: ""},
+	}
+
+	urls := make([]string, 0, len(expected))
+	for _, e := range expected {
+		urls = append(urls, srv.URL+e.path)
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:           urls,
+		Workers:        4,
+		TimeoutMS:      2000,
+		DetectProvider: true,
+	})
+
+	require.Len(t, got, len(urls))
+	for i, e := range expected {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Equal(t, http.StatusOK, got[i].StatusCode)
+		require.Equal(t, e.provider, got[i].Provider, "path %s", e.path)
+	}
+}
+
+func TestCrawlDetectProviderDisabled(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("CF-Ray", "8c1e2f3a4b5c6d7e-AMS")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].Provider)
+}
+
+type fakeCNAMEResolver struct {
+	cnames  map[string]string
+	lookups atomic.Int64
+}
+
+func (r *fakeCNAMEResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	r.lookups.Add(1)
+
+	cname, ok := r.cnames[host]
+	if !ok {
+		return "", &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	return cname, nil
+}
+
+// setProviderResolver подменяет CNAME-резолвер. Вызывать до старта краулера.
+func setProviderResolver(t *testing.T, r cnameResolver) {
+	t.Helper()
+
+	prev := providerResolver
+	providerResolver = r
+
+	t.Cleanup(func() {
+		providerResolver = prev
+	})
+}
+
+func TestCrawlDetectProviderCNAME(t *testing.T) {
+	resolver := &fakeCNAMEResolver{cnames: map[string]string{
+		"localhost": "d111111abcdef8.CloudFront.net.",
+	}}
+	setProviderResolver(t, resolver)
+
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cf-ray" {
+			w.Header().Set("CF-Ray", "8c1e2f3a4b5c6d7e-AMS")
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	byName := "http://localhost:" + u.Port()
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:           []string{byName + "/plain", byName + "/cf-ray", srv.URL + "/by-ip"},
+		Workers:        1,
+		TimeoutMS:      2000,
+		DetectProvider: true,
+	})
+
+	require.Len(t, got, 3)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Equal(t, "cloudfront", got[0].Provider, "CNAME без заголовков")
+	require.Equal(t, "cloudflare", got[1].Provider, "заголовки проверяются раньше CNAME")
+	require.Empty(t, got[2].Provider, "для IP-адреса CNAME не запрашивается")
+	require.Equal(t, int64(1), resolver.lookups.Load(), "CNAME хоста кэшируется")
+
+	// новые пути, чтобы не попасть в кэш ответов
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:           []string{byName + "/plain-2"},
+		Workers:        1,
+		TimeoutMS:      2000,
+		DetectProvider: true,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, "cloudfront", got[0].Provider)
+	require.Equal(t, int64(1), resolver.lookups.Load(), "CNAME из кэша до истечения cacheTTL")
+
+	clk.Advance(cacheTTL + time.Millisecond)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:           []string{byName + "/plain-3"},
+		Workers:        1,
+		TimeoutMS:      2000,
+		DetectProvider: true,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, "cloudfront", got[0].Provider)
+	require.Equal(t, int64(2), resolver.lookups.Load(), "после cacheTTL CNAME запрашивается заново")
+}
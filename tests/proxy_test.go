@@ -0,0 +1,609 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const errorCodeProxyFailed = "proxy_failed"
+
+type proxiedRequest struct {
+	URL  string
+	Auth string
+}
+
+// newForwardProxy - http-прокси, который не ходит дальше, а сам отвечает на урлы в абсолютной форме
+func newForwardProxy(t *testing.T) (srv *httptest.Server, requests func() []proxiedRequest) {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		seen []proxiedRequest
+	)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.IsAbs() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		seen = append(seen, proxiedRequest{URL: r.URL.String(), Auth: r.Header.Get("Proxy-Authorization")})
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusNonAuthoritativeInfo)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv, func() []proxiedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+
+		out := make([]proxiedRequest, len(seen))
+		copy(out, seen)
+
+		return out
+	}
+}
+
+// tunnelTargets запоминает адреса, к которым просили подключиться туннельные прокси
+type tunnelTargets struct {
+	mu    sync.Mutex
+	addrs []string
+}
+
+func (tt *tunnelTargets) add(addr string) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	tt.addrs = append(tt.addrs, addr)
+}
+
+func (tt *tunnelTargets) get() []string {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	out := make([]string, len(tt.addrs))
+	copy(out, tt.addrs)
+
+	return out
+}
+
+// pipe гоняет байты между соединениями, пока одна из сторон не закроется
+func pipe(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+
+	go func() {
+		_, _ = io.Copy(a, b)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		_, _ = io.Copy(b, a)
+		done <- struct{}{}
+	}()
+
+	<-done
+
+	a.Close()
+	b.Close()
+}
+
+// newConnectProxy - https-прокси: на CONNECT к любому адресу открывает туннель к backend
+func newConnectProxy(t *testing.T, backend string) (srv *httptest.Server, targets *tunnelTargets) {
+	t.Helper()
+
+	targets = &tunnelTargets{}
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		targets.add(r.Host)
+
+		upstream, err := net.Dial("tcp", backend)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			upstream.Close()
+			return
+		}
+
+		conn, _, err := hj.Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		pipe(conn, upstream)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv, targets
+}
+
+// newSOCKS5Proxy - минимальный SOCKS5 (RFC 1928, RFC 1929): любой CONNECT соединяется с backend.
+// С непустым user требует username/password-аутентификацию
+func newSOCKS5Proxy(t *testing.T, backend, user, password string) (addr string, targets *tunnelTargets) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	targets = &tunnelTargets{}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		conns = make(map[net.Conn]struct{})
+	)
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+
+		// приветствие: VER, NMETHODS, METHODS
+		head := make([]byte, 2)
+		if _, err := io.ReadFull(r, head); err != nil || head[0] != 5 {
+			return
+		}
+
+		methods := make([]byte, head[1])
+		if _, err := io.ReadFull(r, methods); err != nil {
+			return
+		}
+
+		if user == "" {
+			_, _ = conn.Write([]byte{5, 0})
+		} else {
+			_, _ = conn.Write([]byte{5, 2})
+
+			// VER=1, ULEN, UNAME, PLEN, PASSWD
+			field := func() string {
+				n, err := r.ReadByte()
+				if err != nil {
+					return ""
+				}
+
+				b := make([]byte, n)
+				if _, err := io.ReadFull(r, b); err != nil {
+					return ""
+				}
+
+				return string(b)
+			}
+
+			if v, err := r.ReadByte(); err != nil || v != 1 {
+				return
+			}
+
+			if field() != user || field() != password {
+				_, _ = conn.Write([]byte{1, 1})
+				return
+			}
+
+			_, _ = conn.Write([]byte{1, 0})
+		}
+
+		// запрос: VER, CMD, RSV, ATYP, DST.ADDR, DST.PORT
+		req := make([]byte, 4)
+		if _, err := io.ReadFull(r, req); err != nil || req[1] != 1 {
+			return
+		}
+
+		var host string
+		switch req[3] {
+		case 1, 4:
+			ip := make([]byte, 4)
+			if req[3] == 4 {
+				ip = make([]byte, 16)
+			}
+
+			if _, err := io.ReadFull(r, ip); err != nil {
+				return
+			}
+
+			host = net.IP(ip).String()
+		case 3:
+			n, err := r.ReadByte()
+			if err != nil {
+				return
+			}
+
+			name := make([]byte, n)
+			if _, err := io.ReadFull(r, name); err != nil {
+				return
+			}
+
+			host = string(name)
+		default:
+			return
+		}
+
+		port := make([]byte, 2)
+		if _, err := io.ReadFull(r, port); err != nil {
+			return
+		}
+
+		targets.add(net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+
+		upstream, err := net.Dial("tcp", backend)
+		if err != nil {
+			_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+			return
+		}
+
+		_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+		pipe(conn, upstream)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			conns[conn] = struct{}{}
+			mu.Unlock()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				serve(conn)
+
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+			}()
+		}
+	}()
+
+	t.Cleanup(func() {
+		ln.Close()
+
+		mu.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+
+		wg.Wait()
+	})
+
+	return ln.Addr().String(), targets
+}
+
+func TestCrawlProxy(t *testing.T) {
+	defaultProxy, defaultSeen := newForwardProxy(t)
+	override, overrideSeen := newForwardProxy(t)
+
+	proxyURL, err := url.Parse(defaultProxy.URL)
+	require.NoError(t, err)
+
+	baseUrl, stopWait := serveCrawler(t.Context(), t, New(WithProxy(proxyURL)))
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	direct := newStatusServer(t)
+
+	t.Run("default", func(t *testing.T) {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{"http://site.test/default"},
+			Workers:   1,
+			TimeoutMS: 2000,
+		})
+
+		require.Len(t, got, 1)
+		require.Equal(t, http.StatusNonAuthoritativeInfo, got[0].StatusCode)
+		require.Equal(t, defaultProxy.URL, got[0].Proxy)
+		require.Equal(t, defaultProxy.Listener.Addr().String(), got[0].RemoteAddr)
+		require.Empty(t, got[0].ResolvedAddrs, "the proxy resolves the host")
+		require.Equal(t, []proxiedRequest{{URL: "http://site.test/default"}}, defaultSeen())
+	})
+
+	t.Run("override", func(t *testing.T) {
+		withAuth, err := url.Parse(override.URL)
+		require.NoError(t, err)
+
+		withAuth.User = url.UserPassword("crawler", "s3cret")
+
+		// тот же урл, что и через прокси по умолчанию: прокси входит в ключ кэша
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{"http://site.test/default"},
+			Workers:   1,
+			TimeoutMS: 2000,
+			Proxy:     withAuth.String(),
+		})
+
+		require.Len(t, got, 1)
+		require.Equal(t, http.StatusNonAuthoritativeInfo, got[0].StatusCode)
+		require.Equal(t, withAuth.Redacted(), got[0].Proxy)
+		require.NotContains(t, got[0].Proxy, "s3cret")
+
+		seen := overrideSeen()
+		require.Len(t, seen, 1)
+		require.Equal(t, "http://site.test/default", seen[0].URL)
+		require.Equal(t, "Basic Y3Jhd2xlcjpzM2NyZXQ=", seen[0].Auth)
+
+		require.Len(t, defaultSeen(), 1, "override must bypass the default proxy")
+	})
+
+	t.Run("direct", func(t *testing.T) {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{direct.URL + "/direct"},
+			Workers:   1,
+			TimeoutMS: 2000,
+			Proxy:     "direct",
+		})
+
+		require.Len(t, got, 1)
+		require.Equal(t, http.StatusOK, got[0].StatusCode)
+		require.Empty(t, got[0].Proxy)
+		require.Len(t, defaultSeen(), 1)
+	})
+
+	t.Run("isolated transport", func(t *testing.T) {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:              []string{"http://site.test/isolated"},
+			Workers:           1,
+			TimeoutMS:         2000,
+			IsolatedTransport: true,
+		})
+
+		require.Len(t, got, 1)
+		require.Equal(t, http.StatusNonAuthoritativeInfo, got[0].StatusCode)
+		require.Equal(t, defaultProxy.URL, got[0].Proxy)
+		require.Len(t, defaultSeen(), 2)
+	})
+
+	t.Run("unreachable proxy", func(t *testing.T) {
+		req := CrawlRequest{
+			URLs:      []string{"http://site.test/unreachable"},
+			Workers:   1,
+			TimeoutMS: 2000,
+			Proxy:     closedServerURL(t).String(),
+		}
+
+		got := crawl(t, c, baseUrl, req)
+
+		require.Len(t, got, 1)
+		require.Equal(t, errorCodeProxyFailed, got[0].ErrorCode)
+		require.False(t, got[0].Success)
+
+		// proxy_failed не кэшируется: рабочий прокси получает тот же урл
+		req.Proxy = ""
+		got = crawl(t, c, baseUrl, req)
+
+		require.Len(t, got, 1)
+		require.Equal(t, http.StatusNonAuthoritativeInfo, got[0].StatusCode)
+		require.Equal(t, defaultProxy.URL, got[0].Proxy)
+		require.Len(t, defaultSeen(), 3)
+	})
+}
+
+func TestCrawlProxySOCKS5(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	backend := newStatusServer(t)
+
+	addr, targets := newSOCKS5Proxy(t, backend.Listener.Addr().String(), "crawler", "s3cret")
+
+	for _, scheme := range []string{"socks5", "socks5h"} {
+		proxy := &url.URL{Scheme: scheme, User: url.UserPassword("crawler", "s3cret"), Host: addr}
+
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{"http://site.test/" + scheme + "/203"},
+			Workers:   1,
+			TimeoutMS: 2000,
+			Proxy:     proxy.String(),
+		})
+
+		require.Len(t, got, 1)
+		require.Equal(t, http.StatusNonAuthoritativeInfo, got[0].StatusCode, scheme)
+		require.Equal(t, proxy.Redacted(), got[0].Proxy)
+		require.Equal(t, addr, got[0].RemoteAddr)
+	}
+
+	// имя хоста передаётся прокси
+	require.Equal(t, []string{"site.test:80", "site.test:80"}, targets.get())
+
+	// неверный пароль - отказ SOCKS5
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://site.test/wrong-password/200"},
+		Workers:   1,
+		TimeoutMS: 2000,
+		Proxy:     (&url.URL{Scheme: "socks5", User: url.UserPassword("crawler", "wrong"), Host: addr}).String(),
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, errorCodeProxyFailed, got[0].ErrorCode)
+	require.Len(t, targets.get(), 2)
+}
+
+func TestCrawlProxyHTTPSConnect(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(backend.Close)
+
+	// сертификат httptest выписан в том числе на example.com
+	trustServer(t, backend)
+
+	proxy, targets := newConnectProxy(t, backend.Listener.Addr().String())
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:      []string{"https://example.com/connect"},
+		Workers:   1,
+		TimeoutMS: 2000,
+		Proxy:     proxy.URL,
+	})
+
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].Error)
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+	require.Equal(t, proxy.URL, got[0].Proxy)
+	require.Equal(t, []string{"example.com:443"}, targets.get())
+}
+
+func TestCrawlProxyFailureSkipsBreaker(t *testing.T) {
+	// одной ошибки хоста хватило бы, чтобы открыть circuit breaker
+	t.Setenv(breakerThresholdEnv, "1")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+	dead := closedServerURL(t).String()
+
+	for i := range 3 {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{srv.URL + "/proxied-" + strconv.Itoa(i)},
+			Workers:   1,
+			TimeoutMS: 2000,
+			Proxy:     dead,
+		})
+
+		require.Len(t, got, 1)
+		require.Equal(t, errorCodeProxyFailed, got[0].ErrorCode)
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/direct"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Empty(t, getQuarantine(t, c, baseUrl))
+}
+
+func TestCrawlProxyAllowedNetworks(t *testing.T) {
+	setDNSResolver(t, &fakeResolver{addrs: map[string][]net.IPAddr{
+		"internal.test": {{IP: net.ParseIP("127.0.0.1")}},
+		"public.test":   {{IP: net.ParseIP("10.1.2.3")}},
+	}})
+
+	t.Setenv(allowedNetworksEnv, "10.0.0.0/8")
+
+	proxy, seen := newForwardProxy(t)
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	baseUrl, stopWait := serveCrawler(t.Context(), t, New(WithProxy(proxyURL)))
+	t.Cleanup(stopWait)
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:      []string{"http://internal.test/", "http://public.test/"},
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 2)
+
+	// имя проверяется самим краулером, до обращения к прокси
+	require.Equal(t, errorCodeHostNotAllowed, got[0].ErrorCode)
+
+	require.Equal(t, http.StatusNonAuthoritativeInfo, got[1].StatusCode)
+	require.Equal(t, []string{"10.1.2.3"}, got[1].ResolvedAddrs)
+
+	require.Equal(t, []proxiedRequest{{URL: "http://public.test/"}}, seen())
+}
+
+func TestCrawlProxyValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, proxy := range []string{"ftp://proxy.test:21", "socks4://proxy.test:1080", "http://", "not a url"} {
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{"http://127.0.0.1/"},
+			Workers:   1,
+			TimeoutMS: 1000,
+			Proxy:     proxy,
+		})
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, proxy)
+	}
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://127.0.0.1/"},
+		Workers:   1,
+		TimeoutMS: 1000,
+		Proxy:     "http://127.0.0.1:1",
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestCrawlProxyOptionValidation(t *testing.T) {
+	requireStartError(t, New(WithProxy(&url.URL{Scheme: "ftp", Host: "proxy.test:21"})))
+
+	proxyURL := &url.URL{Scheme: "http", Host: "proxy.test:3128"}
+	requireStartError(t, New(WithTransport(&countingTransport{}), WithProxy(proxyURL)))
+}
+
+func TestCrawlProxyRequiresAdmin(t *testing.T) {
+	setAPIKeyRoles(t, map[string]string{
+		"s": roleSubmitter,
+		"a": roleAdmin,
+	})
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	proxy, seen := newForwardProxy(t)
+
+	c := client()
+	target := constructCrawlPath(t, baseUrl)
+	req := CrawlRequest{
+		URLs:      []string{"http://site.test/admin"},
+		Workers:   1,
+		TimeoutMS: 2000,
+		Proxy:     proxy.URL,
+	}
+
+	resp := postWithKey(t, c, target, "s", req)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Empty(t, seen())
+
+	resp = postWithKey(t, c, target, "a", req)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, seen(), 1)
+}
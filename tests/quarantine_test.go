@@ -0,0 +1,177 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	quarantinePath           = "/admin/quarantine"
+	errorCodeHostQuarantined = "host_quarantined"
+	quarantineReasonErrors   = "errors"
+)
+
+type quarantineEntry struct {
+	Host   string    `json:"host"`
+	Reason string    `json:"reason"`
+	Errors int       `json:"errors"`
+	Until  time.Time `json:"until"`
+}
+
+func getQuarantine(t *testing.T, c *http.Client, baseURL *url.URL) []quarantineEntry {
+	t.Helper()
+
+	resp, err := c.Get(baseURL.JoinPath(quarantinePath).String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var entries []quarantineEntry
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&entries))
+
+	return entries
+}
+
+func liftQuarantine(t *testing.T, c *http.Client, baseURL *url.URL, host string) int {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodDelete, baseURL.JoinPath(quarantinePath, host).String(), nil)
+	require.NoError(t, err)
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+// closedServerURL возвращает адрес, на котором никто не слушает
+func closedServerURL(t *testing.T) *url.URL {
+	t.Helper()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	return u
+}
+
+func TestQuarantineAfterErrors(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+	dead := closedServerURL(t)
+
+	next := 0
+	crawlDead := func() CrawlResponse {
+		next++
+
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{dead.String() + "/" + strconv.Itoa(next)},
+			Workers:   1,
+			TimeoutMS: 1000,
+		})
+
+		require.Len(t, got, 1)
+		return got[0]
+	}
+
+	// quarantine дожидается карантина после failures ошибок подряд
+	quarantine := func(failures int) {
+		for range failures {
+			require.Equal(t, errorCodeFetchFailed, crawlDead().ErrorCode)
+		}
+
+		require.Equal(t, errorCodeHostQuarantined, crawlDead().ErrorCode)
+	}
+
+	require.Empty(t, getQuarantine(t, c, baseUrl))
+
+	quarantine(quarantineErrorThreshold)
+
+	entries := getQuarantine(t, c, baseUrl)
+	require.Len(t, entries, 1)
+	require.Equal(t, dead.Host, entries[0].Host)
+	require.Equal(t, quarantineReasonErrors, entries[0].Reason)
+	require.Equal(t, quarantineErrorThreshold, entries[0].Errors)
+	require.WithinDuration(t, clk.Now().Add(quarantineCooldown), entries[0].Until, time.Second)
+
+	require.Equal(t, http.StatusNoContent, liftQuarantine(t, c, baseUrl, dead.Host))
+	require.Empty(t, getQuarantine(t, c, baseUrl))
+	require.Equal(t, http.StatusNotFound, liftQuarantine(t, c, baseUrl, dead.Host))
+
+	// после снятия счётчик начинается заново: эта ошибка - первая из quarantineErrorThreshold
+	require.Equal(t, errorCodeFetchFailed, crawlDead().ErrorCode)
+	require.Empty(t, getQuarantine(t, c, baseUrl))
+
+	clk.Advance(time.Second)
+	quarantine(quarantineErrorThreshold - 1)
+	require.Len(t, getQuarantine(t, c, baseUrl), 1)
+
+	clk.Advance(quarantineCooldown + time.Second)
+	require.Empty(t, getQuarantine(t, c, baseUrl))
+	require.Equal(t, errorCodeFetchFailed, crawlDead().ErrorCode)
+}
+
+func TestQuarantineResetOnResponse(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var alive atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !alive.Load() {
+			// обработчик работает не в горутине теста, поэтому без require
+			hj, ok := w.(http.Hijacker)
+			if !ok {
+				return
+			}
+
+			conn, _, err := hj.Hijack()
+			if err != nil {
+				return
+			}
+
+			conn.Close()
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	urls := makeURLs(t, srv.URL, 2*quarantineErrorThreshold-1)
+
+	// запросы идут последовательно, поэтому чередование ответов детерминировано
+	for i, u := range urls {
+		alive.Store(i == quarantineErrorThreshold-1)
+
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{u},
+			Workers:   1,
+			TimeoutMS: 1000,
+		})
+
+		require.Len(t, got, 1)
+		require.NotEqual(t, errorCodeHostQuarantined, got[0].ErrorCode, "url %d", i)
+	}
+
+	require.Empty(t, getQuarantine(t, c, baseUrl))
+}
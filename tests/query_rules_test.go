@@ -0,0 +1,135 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setQueryRules(t *testing.T, drop []string, rules []queryRule) {
+	t.Helper()
+
+	prevDrop, prevRules := dropQueryParams, queryRules
+	dropQueryParams, queryRules = drop, rules
+
+	t.Cleanup(func() {
+		dropQueryParams, queryRules = prevDrop, prevRules
+	})
+}
+
+func TestNormalizeURLKeepsQueryByDefault(t *testing.T) {
+	require.Empty(t, dropQueryParams)
+	require.Empty(t, queryRules)
+
+	n, err := normalizeURL("http://example.com/p?utm_source=x&gclid=y")
+	require.NoError(t, err)
+	require.Equal(t, "http://example.com/p?gclid=y&utm_source=x", n)
+}
+
+func TestNormalizeURLDropsQueryParams(t *testing.T) {
+	setQueryRules(t, []string{"utm_*", "gclid", "fbclid"}, []queryRule{
+		{hostPattern: "*.shop.test", keep: []string{"id"}},
+	})
+
+	cases := []struct {
+		mode string
+		a, b string
+	}{
+		{mode: "standard", a: "http://example.com/p?utm_source=x&b=2&gclid=y&a=1", b: "http://example.com/p?a=1&b=2"},
+		{mode: "standard", a: "http://example.com/p?fbclid=z&utm_medium=m", b: "http://example.com/p"},
+		{mode: "standard", a: "http://www.shop.test/item?ref=ad&id=7&color=red", b: "http://www.shop.test/item?id=7"},
+		{mode: "strict", a: "http://example.com/p?b=2&utm_campaign=c&a=1", b: "http://example.com/p?b=2&a=1"},
+	}
+
+	for _, tc := range cases {
+		a, err := normalizeURLWith(tc.a, tc.mode)
+		require.NoError(t, err)
+
+		b, err := normalizeURLWith(tc.b, tc.mode)
+		require.NoError(t, err)
+
+		require.Equal(t, b, a, "%s: %q", tc.mode, tc.a)
+		require.NotContains(t, a, "utm_")
+
+		again, err := normalizeURLWith(a, tc.mode)
+		require.NoError(t, err)
+		require.Equal(t, a, again, "%s is not idempotent for %q", tc.mode, tc.a)
+	}
+
+	n, err := normalizeURL("http://example.com/p?utm_source=x")
+	require.NoError(t, err)
+	require.NotContains(t, n, "?")
+
+	// whitelist хоста отменяет общий список: utm_source тоже не значим, а id значим
+	n, err = normalizeURL("http://www.shop.test/item?id=7&utm_source=x")
+	require.NoError(t, err)
+	require.Contains(t, n, "id=7")
+	require.NotContains(t, n, "utm_source")
+
+	// в режиме off параметры не трогаются
+	a, err := normalizeURLWith("http://example.com/p?utm_source=x", "off")
+	require.NoError(t, err)
+
+	b, err := normalizeURLWith("http://example.com/p", "off")
+	require.NoError(t, err)
+	require.NotEqual(t, a, b)
+}
+
+func TestCrawlDedupByStrippedQuery(t *testing.T) {
+	setQueryRules(t, []string{"utm_*", "gclid"}, nil)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var (
+		hits    atomic.Int64
+		mu      sync.Mutex
+		queries []string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		mu.Lock()
+		queries = append(queries, r.URL.RawQuery)
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	urls := []string{
+		srv.URL + "/landing?utm_source=mail&page=1",
+		srv.URL + "/landing?page=1&utm_source=banner&gclid=abc",
+		srv.URL + "/landing?page=1",
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   3,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, len(urls))
+	for i, r := range got {
+		require.Equal(t, urls[i], r.URL)
+		require.Empty(t, r.Error)
+		require.Equal(t, http.StatusOK, r.StatusCode)
+	}
+
+	require.EqualValues(t, 1, hits.Load())
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Equal(t, []string{"page=1"}, queries)
+}
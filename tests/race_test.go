@@ -5,8 +5,11 @@ package crawler
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"sync"
 	"sync/atomic"
 	"testing"
 
@@ -69,3 +72,50 @@ func TestCrawlRace(t *testing.T) {
 		require.Equal(t, http.StatusNoContent, got[i].StatusCode)
 	}
 }
+
+func TestNormalizeCacheRace(t *testing.T) {
+	const (
+		size       = 16
+		goroutines = 8
+		n          = 1000
+	)
+
+	c := newNormalizeCache(size)
+	wg := new(sync.WaitGroup)
+
+	// require нельзя вызывать из горутин: первое расхождение каждой горутины проверяется после Wait
+	errs := make([]error, goroutines)
+
+	for g := range goroutines {
+		wg.Go(func() {
+			for i := range n {
+				raw := "http://example.com/" + strconv.Itoa((g*n+i)%(2*size))
+
+				expected, err := normalizeURL(raw)
+				if err != nil {
+					errs[g] = err
+					return
+				}
+
+				got, err := c.normalize(raw)
+				if err != nil {
+					errs[g] = err
+					return
+				}
+
+				if got != expected {
+					errs[g] = fmt.Errorf("normalize(%q) = %q, want %q", raw, got, expected)
+					return
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.LessOrEqual(t, c.len(), size)
+}
@@ -0,0 +1,122 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalRateLimit(t *testing.T) {
+	const (
+		rps = 10
+		n   = 5
+	)
+
+	// token bucket живёт на clock: токены появляются только через Advance, а кэш не истекает сам
+	clk := newFakeClock()
+
+	baseUrl, stopWait := serveCrawler(t.Context(), t, newCrawler(clk, WithGlobalRateLimit(rps, 1)))
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	// два обхода одновременно делят один лимит
+	first := makeURLs(t, srv.URL+"/first", n)
+	second := makeURLs(t, srv.URL+"/second", n)
+
+	pending := []<-chan crawlResult{
+		crawlAsync(c, baseUrl, CrawlRequest{URLs: first, Workers: n, TimeoutMS: 5000}),
+		crawlAsync(c, baseUrl, CrawlRequest{URLs: second, Workers: n, TimeoutMS: 5000}),
+	}
+
+	// burst 1: сразу уходит один запрос, каждые 1/rps - ещё один
+	for k := int64(1); k < 2*n; k++ {
+		require.Eventually(t, func() bool {
+			return hits.Load() >= k
+		}, time.Second, 5*time.Millisecond)
+
+		require.Equal(t, k, hits.Load())
+		clk.Advance(time.Second / rps)
+	}
+
+	for _, ch := range pending {
+		got := awaitCrawl(t, ch)
+
+		require.Len(t, got, n)
+		for _, r := range got {
+			require.Equal(t, http.StatusOK, r.StatusCode, r.URL)
+		}
+	}
+
+	require.EqualValues(t, 2*n, hits.Load())
+
+	// ответы из кэша токены не тратят: бакет пуст, а время не двигается
+	cached := crawlAsync(c, baseUrl, CrawlRequest{URLs: first, Workers: n, TimeoutMS: 5000})
+
+	select {
+	case res := <-cached:
+		require.NoError(t, res.err)
+		require.Len(t, res.results, n)
+	case <-time.After(2 * time.Second):
+		t.Fatal("cached crawl waits for rate limit tokens")
+	}
+
+	require.EqualValues(t, 2*n, hits.Load())
+}
+
+func TestGlobalRateLimitTimeout(t *testing.T) {
+	baseUrl, stopWait := serveCrawler(t.Context(), t, New(WithGlobalRateLimit(1, 1)))
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL, 3),
+		Workers:   3,
+		TimeoutMS: 300,
+	})
+
+	require.Len(t, got, 3)
+
+	timeouts := 0
+	for _, r := range got {
+		if r.ErrorCode == errorCodeTimeout {
+			timeouts++
+		}
+	}
+
+	require.Equal(t, 2, timeouts)
+}
+
+func TestGlobalRateLimitDisabled(t *testing.T) {
+	baseUrl, stopWait := serveCrawler(t.Context(), t, New(WithGlobalRateLimit(0, 0)))
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	start := time.Now()
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL, 50),
+		Workers:   10,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 50)
+	require.Less(t, time.Since(start), time.Second)
+}
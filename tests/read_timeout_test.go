@@ -0,0 +1,162 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	readTimeoutSlack = time.Second
+	readTimeoutEnv   = "CRAWLER_READ_TIMEOUT_MS"
+)
+
+// sendPartialBody открывает соединение и отправляет заголовки /crawl и только начало тела
+func sendPartialBody(t *testing.T, baseUrl *url.URL) net.Conn {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", baseUrl.Host)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	const body = `{"urls":["http://example.com"],"workers":1,"timeout_ms":1000}`
+
+	_, err = fmt.Fprintf(conn, "POST %s HTTP/1.1\r\nHost: %s\r\nContent-Type: %s\r\nContent-Length: %d\r\n\r\n%s",
+		crawlPath, baseUrl.Host, contentTypeJson, len(body), body[:10])
+	require.NoError(t, err)
+
+	return conn
+}
+
+func TestCrawlSlowBody(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	conn := sendPartialBody(t, baseUrl)
+
+	start := time.Now()
+	require.NoError(t, conn.SetReadDeadline(start.Add(requestReadTimeout+readTimeoutSlack)))
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err, "server must answer before the client finishes the body")
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	require.Equal(t, http.StatusRequestTimeout, resp.StatusCode)
+	require.GreaterOrEqual(t, time.Since(start), requestReadTimeout-readTimeoutSlack)
+}
+
+func TestCrawlSlowHeaders(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	conn, err := net.Dial("tcp", baseUrl.Host)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	_, err = fmt.Fprintf(conn, "POST %s HTTP/1.1\r\nHost: %s\r\n", crawlPath, baseUrl.Host)
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, conn.SetReadDeadline(start.Add(requestReadTimeout+readTimeoutSlack)))
+
+	// hint: сервер должен сам закрыть соединение, не дождавшись конца заголовков
+	_, err = io.ReadAll(conn)
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), requestReadTimeout+readTimeoutSlack)
+}
+
+func TestCrawlReadTimeoutDoesNotLimitCrawl(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(requestReadTimeout + readTimeoutSlack)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL},
+		Workers:   1,
+		TimeoutMS: int((3 * requestReadTimeout).Milliseconds()),
+	})
+
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].Error)
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+}
+
+func TestCrawlReadTimeoutConfigured(t *testing.T) {
+	const timeout = 300 * time.Millisecond
+
+	t.Setenv(readTimeoutEnv, strconv.Itoa(int(timeout.Milliseconds())))
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	conn := sendPartialBody(t, baseUrl)
+
+	start := time.Now()
+	require.NoError(t, conn.SetReadDeadline(start.Add(requestReadTimeout)))
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err, "server must use the configured read timeout, not requestReadTimeout")
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	require.Equal(t, http.StatusRequestTimeout, resp.StatusCode)
+	require.GreaterOrEqual(t, time.Since(start), timeout-100*time.Millisecond)
+}
+
+func TestCrawlReadTimeoutInvalid(t *testing.T) {
+	for _, v := range []string{"0", "-5", "slow"} {
+		t.Setenv(readTimeoutEnv, v)
+		requireStartError(t, New(), v)
+	}
+}
+
+func TestCrawlClientCancelsMidBody(t *testing.T) {
+	// таймаут чтения заведомо больше ожидания в тесте: обработчик должна завершить отмена, а не он
+	t.Setenv(readTimeoutEnv, strconv.Itoa(int((30 * time.Second).Milliseconds())))
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	conn := sendPartialBody(t, baseUrl)
+
+	require.Eventually(t, func() bool {
+		code, st, err := drainRequest(c, baseUrl, http.MethodGet, "")
+		return err == nil && code == http.StatusOK && st.InFlight == 1
+	}, time.Second, 10*time.Millisecond, "handler must be reading the body")
+
+	require.NoError(t, conn.Close())
+
+	require.Eventually(t, drainIdle(c, baseUrl), time.Second, 10*time.Millisecond,
+		"handler must return once the client cancels, not after the read timeout")
+}
@@ -0,0 +1,195 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	recentErrorsPath     = "/debug/recent-errors"
+	errorCodeFetchFailed = "fetch_failed"
+	errorCodeTimeout     = "timeout"
+)
+
+type recentError struct {
+	URL       string    `json:"url"`
+	Host      string    `json:"host"`
+	ErrorCode string    `json:"error_code"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func getRecentErrors(t *testing.T, c *http.Client, baseURL *url.URL) []recentError {
+	t.Helper()
+
+	resp, err := c.Get(baseURL.JoinPath(recentErrorsPath).String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got []recentError
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	return got
+}
+
+func TestRecentErrors(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	require.Empty(t, getRecentErrors(t, c, baseUrl))
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(ok.Close)
+
+	closedURL, err := url.Parse(closed.URL)
+	require.NoError(t, err)
+
+	before := time.Now()
+
+	crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{closed.URL + "/first", ok.URL},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://example.com:abc"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	got := getRecentErrors(t, c, baseUrl)
+	require.Len(t, got, 2)
+
+	require.Equal(t, "http://example.com:abc", got[0].URL)
+	require.Equal(t, errorCodeInvalidURL, got[0].ErrorCode)
+
+	require.Equal(t, closed.URL+"/first", got[1].URL)
+	require.Equal(t, closedURL.Host, got[1].Host)
+	require.Equal(t, errorCodeFetchFailed, got[1].ErrorCode)
+
+	for _, e := range got {
+		require.False(t, e.Timestamp.Before(before))
+		require.False(t, e.Timestamp.After(time.Now()))
+	}
+
+	require.False(t, got[0].Timestamp.Before(got[1].Timestamp))
+}
+
+func TestRecentErrorsBounded(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	const extra = 20
+	urls := makeURLs(t, closed.URL, recentErrorsSize+extra)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   1,
+		TimeoutMS: 5000,
+	})
+
+	require.Len(t, got, len(urls))
+
+	recent := getRecentErrors(t, c, baseUrl)
+	require.Len(t, recent, recentErrorsSize)
+
+	// workers: 1 - ошибки появляются строго в порядке урлов
+	for i, e := range recent {
+		require.Equal(t, urls[len(urls)-1-i], e.URL)
+	}
+}
+
+func TestRecentErrorsSkipCached(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	req := CrawlRequest{
+		URLs:      []string{closed.URL},
+		Workers:   1,
+		TimeoutMS: 2000,
+	}
+
+	for range 3 {
+		got := crawl(t, c, baseUrl, req)
+		require.Len(t, got, 1)
+		require.NotEmpty(t, got[0].Error)
+	}
+
+	require.Len(t, getRecentErrors(t, c, baseUrl), 1)
+}
+
+func TestRecentErrorsCachedUntilTTL(t *testing.T) {
+	clk := newFakeClock()
+
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var hits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		// обрываем соединение без ответа - fetch_failed
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL},
+		Workers:   1,
+		TimeoutMS: 2000,
+	}
+
+	for range 2 {
+		got := crawl(t, c, baseUrl, req)
+		require.Len(t, got, 1)
+		require.Equal(t, errorCodeFetchFailed, got[0].ErrorCode)
+	}
+
+	require.EqualValues(t, 1, hits.Load(), "error must be served from cache within cacheTTL")
+	require.Len(t, getRecentErrors(t, c, baseUrl), 1)
+
+	clk.Advance(cacheTTL + 100*time.Millisecond)
+
+	got := crawl(t, c, baseUrl, req)
+	require.Len(t, got, 1)
+	require.Equal(t, errorCodeFetchFailed, got[0].ErrorCode)
+
+	require.EqualValues(t, 2, hits.Load(), "expired error must be fetched again")
+	require.Len(t, getRecentErrors(t, c, baseUrl), 2)
+}
@@ -0,0 +1,201 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newSiteServer отдаёт HTML-страницы из pages (путь -> тело) и запоминает, какие пути запрашивались
+func newSiteServer(t *testing.T, pages func(base string) map[string]string) (srv *httptest.Server, visited func() []string) {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		seen []string
+		site map[string]string
+	)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.URL.Path)
+		mu.Unlock()
+
+		body, ok := site[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(body))
+	}))
+
+	site = pages(srv.URL)
+	t.Cleanup(srv.Close)
+
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string(nil), seen...)
+	}
+}
+
+func links(hrefs ...string) string {
+	body := "<html><body>"
+	for _, h := range hrefs {
+		body += fmt.Sprintf(`<a href="%s">link</a>`, h)
+	}
+
+	return body + "</body></html>"
+}
+
+func TestRecursiveCrawl(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	external, externalVisited := newSiteServer(t, func(string) map[string]string {
+		return map[string]string{"/": links()}
+	})
+
+	srv, visited := newSiteServer(t, func(base string) map[string]string {
+		return map[string]string{
+			"/":      links("/a", "b#section", external.URL+"/", "mailto:x@example.com"),
+			"/a":     links("/c", "/", base+"/a"),
+			"/b":     links("c", "/missing"),
+			"/c":     links("/d"),
+			"/d":     links(),
+			"/other": links("/d"),
+		}
+	})
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/", srv.URL + "/other"},
+		Workers:   4,
+		TimeoutMS: 5000,
+		Depth:     2,
+	})
+
+	type node struct {
+		url, parent string
+		depth, code int
+	}
+
+	want := []node{
+		{url: srv.URL + "/", code: http.StatusOK},
+		{url: srv.URL + "/other", code: http.StatusOK},
+		{url: srv.URL + "/a", parent: srv.URL + "/", depth: 1, code: http.StatusOK},
+		{url: srv.URL + "/b", parent: srv.URL + "/", depth: 1, code: http.StatusOK},
+		{url: srv.URL + "/d", parent: srv.URL + "/other", depth: 1, code: http.StatusOK},
+		{url: srv.URL + "/c", parent: srv.URL + "/a", depth: 2, code: http.StatusOK},
+		{url: srv.URL + "/missing", parent: srv.URL + "/b", depth: 2, code: http.StatusNotFound},
+	}
+
+	require.Len(t, got, len(want))
+
+	for i, w := range want {
+		require.Equal(t, w.url, got[i].URL, "result %d", i)
+		require.Equal(t, w.parent, got[i].Parent, w.url)
+		require.Equal(t, w.depth, got[i].Depth, w.url)
+		require.Equal(t, w.code, got[i].StatusCode, w.url)
+	}
+
+	require.ElementsMatch(t, []string{"/", "/other", "/a", "/b", "/d", "/c", "/missing"}, visited())
+	require.Empty(t, externalVisited())
+}
+
+func TestRecursiveCrawlJobIndexes(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv, _ := newSiteServer(t, func(string) map[string]string {
+		return map[string]string{
+			"/":  links("/a", "/b"),
+			"/a": links(),
+			"/b": links(),
+		}
+	})
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/"},
+		Workers:   2,
+		TimeoutMS: 5000,
+		Depth:     1,
+	})
+
+	byIndex := make(map[int]CrawlResponse)
+	for _, e := range waitJob(t, c, baseUrl, id) {
+		if e.Name != "result" {
+			continue
+		}
+
+		var r sseResult
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &r))
+		byIndex[r.Index] = r.CrawlResponse
+	}
+
+	require.Len(t, byIndex, 3)
+	require.Equal(t, srv.URL+"/", byIndex[0].URL)
+	require.Equal(t, srv.URL+"/a", byIndex[1].URL)
+	require.Equal(t, srv.URL+"/b", byIndex[2].URL)
+	require.Equal(t, srv.URL+"/", byIndex[2].Parent)
+}
+
+func TestRecursiveCrawlNofollow(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv, visited := newSiteServer(t, func(string) map[string]string {
+		return map[string]string{
+			"/":       links("/nf", "/follow"),
+			"/nf":     `<html><head><meta name="robots" content="nofollow"></head><body><a href="/hidden">x</a></body></html>`,
+			"/follow": links("/shown"),
+			"/shown":  links(),
+			"/hidden": links(),
+		}
+	})
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:       []string{srv.URL + "/"},
+		Workers:    2,
+		TimeoutMS:  5000,
+		Depth:      2,
+		RobotsMeta: true,
+	})
+
+	require.Len(t, got, 4)
+	require.NotContains(t, visited(), "/hidden")
+	require.Contains(t, visited(), "/shown")
+}
+
+func TestRecursiveCrawlValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, depth := range []int{-1, 6} {
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{"http://127.0.0.1/"},
+			Workers:   1,
+			TimeoutMS: 1000,
+			Depth:     depth,
+		})
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, depth)
+	}
+}
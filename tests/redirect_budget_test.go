@@ -0,0 +1,149 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	errorCodeRedirectBudgetExceeded = "redirect_budget_exceeded"
+	slowHopDelay                    = 200 * time.Millisecond
+)
+
+// newSlowHopServer: <prefix>/N отвечает через slowHopDelay редиректом на <prefix>/N-1, <prefix>/0 - 200
+func newSlowHopServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(path.Base(r.URL.Path))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		select {
+		case <-time.After(slowHopDelay):
+		case <-r.Context().Done():
+			return
+		}
+
+		if n == 0 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		http.Redirect(w, r, path.Join(path.Dir(r.URL.Path), strconv.Itoa(n-1)), http.StatusFound)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func sumMS(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+
+	return sum
+}
+
+func TestCrawlRedirectHopTimings(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newSlowHopServer(t)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/plain/2", srv.URL + "/plain/0"},
+		Workers:   2,
+		TimeoutMS: 5000,
+	})
+
+	require.Len(t, got, 2)
+
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Len(t, got[0].RedirectChain, 2)
+	require.NotNil(t, got[0].Timings)
+	require.Len(t, got[0].Timings.HopsMS, 3)
+
+	for i, hop := range got[0].Timings.HopsMS {
+		require.GreaterOrEqual(t, hop, float64(slowHopDelay.Milliseconds()), i)
+	}
+
+	require.LessOrEqual(t, sumMS(got[0].Timings.HopsMS), got[0].Timings.TotalMS)
+
+	// без редиректов разбивки по переходам нет
+	require.Equal(t, http.StatusOK, got[1].StatusCode)
+	require.Empty(t, got[1].Timings.HopsMS)
+}
+
+func TestCrawlRedirectBudget(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newSlowHopServer(t)
+
+	// два перехода укладываются в бюджет, третий запрос прерывается на середине
+	start := time.Now()
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:             []string{srv.URL + "/budget/5"},
+		Workers:          1,
+		TimeoutMS:        5000,
+		RedirectBudgetMS: 500,
+	})
+
+	require.Len(t, got, 1)
+	require.Less(t, time.Since(start), 2*time.Second)
+
+	require.Equal(t, errorCodeRedirectBudgetExceeded, got[0].ErrorCode)
+	require.False(t, got[0].Success)
+	require.Zero(t, got[0].StatusCode)
+	require.Equal(t, []RedirectHop{
+		{URL: srv.URL + "/budget/5", StatusCode: http.StatusFound},
+		{URL: srv.URL + "/budget/4", StatusCode: http.StatusFound},
+	}, got[0].RedirectChain)
+
+	require.NotNil(t, got[0].Timings)
+	require.Len(t, got[0].Timings.HopsMS, 3)
+	require.InDelta(t, 500, sumMS(got[0].Timings.HopsMS), 150)
+
+	// короткая цепочка укладывается в бюджет
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:             []string{srv.URL + "/short/1"},
+		Workers:          1,
+		TimeoutMS:        5000,
+		RedirectBudgetMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Len(t, got[0].Timings.HopsMS, 2)
+}
+
+func TestCrawlRedirectBudgetValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	noFollow := false
+
+	for _, req := range []CrawlRequest{
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, RedirectBudgetMS: -1},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, RedirectBudgetMS: 1001},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, RedirectBudgetMS: 500, FollowRedirects: &noFollow},
+	} {
+		resp := postCrawl(t, c, baseUrl, req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+}
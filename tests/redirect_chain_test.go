@@ -0,0 +1,185 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const errorCodeTooManyRedirects = "too_many_redirects"
+
+// newHopServer: /hop/N редиректит на /hop/N-1, /hop/0 отвечает 200
+func newHopServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hop/"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if n == 0 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		code := http.StatusFound
+		if n%2 == 0 {
+			code = http.StatusMovedPermanently
+		}
+
+		http.Redirect(w, r, "/hop/"+strconv.Itoa(n-1), code)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCrawlRedirectChain(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newHopServer(t)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/hop/3", srv.URL + "/hop/0"},
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 2)
+
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Equal(t, srv.URL+"/hop/0", got[0].FinalURL)
+	require.Equal(t, []RedirectHop{
+		{URL: srv.URL + "/hop/3", StatusCode: http.StatusFound},
+		{URL: srv.URL + "/hop/2", StatusCode: http.StatusMovedPermanently},
+		{URL: srv.URL + "/hop/1", StatusCode: http.StatusFound},
+	}, got[0].RedirectChain)
+
+	require.Equal(t, http.StatusOK, got[1].StatusCode)
+	require.Empty(t, got[1].FinalURL)
+	require.Empty(t, got[1].RedirectChain)
+}
+
+func TestCrawlNoFollowRedirects(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newHopServer(t)
+
+	follow := false
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:            []string{srv.URL + "/hop/2"},
+		Workers:         1,
+		TimeoutMS:       2000,
+		FollowRedirects: &follow,
+	})
+
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].Error)
+	require.Equal(t, http.StatusMovedPermanently, got[0].StatusCode)
+	require.Empty(t, got[0].FinalURL)
+	require.Empty(t, got[0].RedirectChain)
+
+	// ответ без следования редиректам не подменяет обычный в кэше
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/hop/2"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Len(t, got[0].RedirectChain, 2)
+}
+
+func TestCrawlMaxRedirects(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newHopServer(t)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:         []string{srv.URL + "/hop/3", srv.URL + "/hop/2"},
+		Workers:      2,
+		TimeoutMS:    2000,
+		MaxRedirects: 2,
+	})
+
+	require.Len(t, got, 2)
+
+	require.Equal(t, errorCodeTooManyRedirects, got[0].ErrorCode)
+	require.False(t, got[0].Success)
+	require.Equal(t, http.StatusFound, got[0].StatusCode)
+	require.Len(t, got[0].RedirectChain, 3)
+	require.Equal(t, srv.URL+"/hop/1", got[0].RedirectChain[2].URL)
+
+	require.Empty(t, got[1].Error)
+	require.Equal(t, http.StatusOK, got[1].StatusCode)
+	require.Len(t, got[1].RedirectChain, 2)
+
+	// по умолчанию разрешено defaultMaxRedirects переходов
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/hop/" + strconv.Itoa(defaultMaxRedirects), srv.URL + "/hop/" + strconv.Itoa(defaultMaxRedirects+1)},
+		Workers:   2,
+		TimeoutMS: 5000,
+	})
+
+	require.Len(t, got, 2)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Len(t, got[0].RedirectChain, defaultMaxRedirects)
+	require.Equal(t, errorCodeTooManyRedirects, got[1].ErrorCode)
+}
+
+func TestCrawlBlockedRedirectChain(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	target := newHopServer(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace(target.URL, "127.0.0.1", "localhost", 1)+"/hop/0", http.StatusFound)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:           []string{srv.URL + "/away"},
+		Workers:        1,
+		TimeoutMS:      2000,
+		RedirectPolicy: "same_host",
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, errorCodeRedirectBlocked, got[0].ErrorCode)
+	require.Equal(t, []RedirectHop{{URL: srv.URL + "/away", StatusCode: http.StatusFound}}, got[0].RedirectChain)
+}
+
+func TestCrawlRedirectOptionsValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	follow := false
+
+	for _, req := range []CrawlRequest{
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, MaxRedirects: -1},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, MaxRedirects: maxRedirectsLimit + 1},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, MaxRedirects: 3, FollowRedirects: &follow},
+	} {
+		resp := postCrawl(t, c, baseUrl, req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+}
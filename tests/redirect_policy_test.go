@@ -0,0 +1,162 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const errorCodeRedirectBlocked = "redirect_blocked"
+
+// newRedirectServer отвечает 200 на /final, а остальные пути редиректит на адрес из next
+func newRedirectServer(t *testing.T, next func(r *http.Request) string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/final" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		http.Redirect(w, r, next(r), http.StatusFound)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCrawlRedirectPolicy(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var foreignHits atomic.Int64
+
+	// другой хост: localhost вместо 127.0.0.1
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		foreignHits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(foreign.Close)
+
+	foreignURL := strings.Replace(foreign.URL, "127.0.0.1", "localhost", 1) + "/landing"
+
+	// тот же хост, другой порт
+	sibling := newRedirectServer(t, func(r *http.Request) string {
+		return "/final"
+	})
+
+	origin := newRedirectServer(t, func(r *http.Request) string {
+		switch r.URL.Path {
+		case "/away":
+			return foreignURL
+		case "/sibling":
+			return sibling.URL + "/hop"
+		default:
+			return "/final"
+		}
+	})
+
+	urls := []string{origin.URL + "/local", origin.URL + "/sibling", origin.URL + "/away"}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:           urls,
+		Workers:        3,
+		TimeoutMS:      2000,
+		RedirectPolicy: "same_host",
+	})
+
+	require.Len(t, got, len(urls))
+
+	for _, r := range got[:2] {
+		require.Empty(t, r.ErrorCode, r.URL)
+		require.Equal(t, http.StatusOK, r.StatusCode, r.URL)
+		require.Empty(t, r.BlockedRedirect)
+	}
+
+	require.Equal(t, urls[2], got[2].URL)
+	require.Equal(t, errorCodeRedirectBlocked, got[2].ErrorCode)
+	require.Equal(t, http.StatusFound, got[2].StatusCode)
+	require.Equal(t, foreignURL, got[2].BlockedRedirect)
+	require.False(t, got[2].Success)
+	require.Zero(t, foreignHits.Load())
+
+	// тот же урл с другой политикой не берётся из кэша
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:           urls[2:],
+		Workers:        1,
+		TimeoutMS:      2000,
+		RedirectPolicy: "allowlist",
+		RedirectAllow:  []string{"local*"},
+	})
+
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].ErrorCode)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.EqualValues(t, 1, foreignHits.Load())
+}
+
+func TestCrawlRedirectPolicyChecksEveryHop(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var finalHits atomic.Int64
+
+	final := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		finalHits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(final.Close)
+
+	// localhost -> 127.0.0.1: второй шаг возвращает на хост, отличный от исходного
+	finalURL := final.URL + "/final"
+	hop := newRedirectServer(t, func(r *http.Request) string {
+		return finalURL
+	})
+
+	hopURL := strings.Replace(hop.URL, "127.0.0.1", "localhost", 1)
+	start := newRedirectServer(t, func(r *http.Request) string {
+		return hopURL + "/hop"
+	})
+
+	startURL := strings.Replace(start.URL, "127.0.0.1", "localhost", 1) + "/start"
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:           []string{startURL},
+		Workers:        1,
+		TimeoutMS:      2000,
+		RedirectPolicy: "same_host",
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, errorCodeRedirectBlocked, got[0].ErrorCode)
+	require.Equal(t, finalURL, got[0].BlockedRedirect)
+	require.Zero(t, finalHits.Load())
+}
+
+func TestCrawlRedirectPolicyValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, req := range []CrawlRequest{
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, RedirectPolicy: "nowhere"},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, RedirectAllow: []string{"*.example.com"}},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, RedirectPolicy: "same_host", RedirectAllow: []string{"x"}},
+	} {
+		resp := postCrawl(t, c, baseUrl, req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+}
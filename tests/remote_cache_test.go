@@ -0,0 +1,261 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRemoteCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+	ttls    map[string]time.Duration
+
+	gets     atomic.Int64
+	getDelay time.Duration
+	err      error
+}
+
+func newFakeRemoteCache() *fakeRemoteCache {
+	return &fakeRemoteCache{
+		entries: make(map[string][]byte),
+		ttls:    make(map[string]time.Duration),
+	}
+}
+
+func (f *fakeRemoteCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	f.gets.Add(1)
+
+	if f.getDelay > 0 {
+		select {
+		case <-time.After(f.getDelay):
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+
+	if f.err != nil {
+		return nil, false, f.err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	v, ok := f.entries[key]
+	return v, ok, nil
+}
+
+func (f *fakeRemoteCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.entries[key] = append([]byte(nil), value...)
+	f.ttls[key] = ttl
+
+	return nil
+}
+
+func (f *fakeRemoteCache) keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := make([]string, 0, len(f.entries))
+	for k := range f.entries {
+		out = append(out, k)
+	}
+
+	return out
+}
+
+// setRemoteCache подменяет L2. Вызывать до старта краулера.
+func setRemoteCache(t *testing.T, r remoteCache) {
+	t.Helper()
+
+	prev := remoteCacheBackend
+	remoteCacheBackend = r
+
+	t.Cleanup(func() {
+		remoteCacheBackend = prev
+	})
+}
+
+func newCountingServer(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+
+	var hits atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestRemoteCacheSharedBetweenInstances(t *testing.T) {
+	remote := newFakeRemoteCache()
+	setRemoteCache(t, remote)
+
+	first, stopFirst := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopFirst)
+
+	second, stopSecond := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopSecond)
+
+	c := client()
+	srv, hits := newCountingServer(t)
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL + "/shared"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	}
+
+	got := crawl(t, c, first, req)
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusAccepted, got[0].StatusCode)
+	require.EqualValues(t, 1, hits.Load())
+
+	// запись в L2 отложенная
+	require.Eventually(t, func() bool {
+		return len(remote.keys()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	key := remote.keys()[0]
+	require.True(t, strings.HasPrefix(key, remoteCacheKeyPrefix), key)
+
+	remote.mu.Lock()
+	ttl := remote.ttls[key]
+	remote.mu.Unlock()
+
+	require.Positive(t, ttl)
+	require.LessOrEqual(t, ttl, cacheTTL)
+
+	got = crawl(t, c, second, req)
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusAccepted, got[0].StatusCode)
+	require.EqualValues(t, 1, hits.Load())
+
+	m := scrapeMetrics(t, c, second)
+	require.InDelta(t, 1, m["crawler_cache_remote_hits_total"], 0)
+
+	// теперь запись есть в L1 второго экземпляра, и L2 не спрашивается
+	gets := remote.gets.Load()
+
+	got = crawl(t, c, second, req)
+	require.Len(t, got, 1)
+	require.Equal(t, gets, remote.gets.Load())
+}
+
+func TestRemoteCacheSlowBackend(t *testing.T) {
+	remote := newFakeRemoteCache()
+	remote.getDelay = time.Second
+	setRemoteCache(t, remote)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, hits := newCountingServer(t)
+
+	start := time.Now()
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/slow-l2"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].Error)
+	require.Equal(t, http.StatusAccepted, got[0].StatusCode)
+	require.EqualValues(t, 1, hits.Load())
+
+	m := scrapeMetrics(t, c, baseUrl)
+	require.InDelta(t, 1, m["crawler_cache_remote_errors_total"], 0)
+}
+
+func TestRemoteCacheErrors(t *testing.T) {
+	remote := newFakeRemoteCache()
+	remote.err = errors.New("connection refused")
+	setRemoteCache(t, remote)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, hits := newCountingServer(t)
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL + "/broken-l2"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	}
+
+	got := crawl(t, c, baseUrl, req)
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].Error)
+	require.Equal(t, http.StatusAccepted, got[0].StatusCode)
+
+	// L1 работает как раньше
+	got = crawl(t, c, baseUrl, req)
+	require.Len(t, got, 1)
+	require.EqualValues(t, 1, hits.Load())
+
+	require.Eventually(t, func() bool {
+		return scrapeRemoteErrors(c, baseUrl) >= 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+// scrapeRemoteErrors - scrapeMetrics без require, для условий Eventually
+func scrapeRemoteErrors(c *http.Client, baseURL *url.URL) float64 {
+	resp, err := c.Get(baseURL.JoinPath(metricsPath).String())
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || name != "crawler_cache_remote_errors_total" {
+			continue
+		}
+
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0
+		}
+
+		return v
+	}
+
+	return 0
+}
+
+func TestRemoteCacheConfig(t *testing.T) {
+	// "redis://" go-redis принял бы как localhost:6379, поэтому номер базы заведомо некорректный
+	for _, value := range []string{"ftp://127.0.0.1:21", "redis://127.0.0.1:6379/not-a-db", "memcache://"} {
+		t.Setenv("CRAWLER_CACHE_REMOTE", value)
+
+		requireStartError(t, New(), value)
+	}
+}
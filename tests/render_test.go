@@ -0,0 +1,230 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	renderURLEnv            = "CRAWLER_RENDER_URL"
+	errorCodeRenderFailed   = "render_failed"
+	renderedScreenshotsRoot = "s3://screenshots/"
+)
+
+// fakeRenderer "рендерит" страницы без браузера: /spa уводит на /app, /broken падает
+type fakeRenderer struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *fakeRenderer) Render(ctx context.Context, u *url.URL, screenshot bool) (renderResult, error) {
+	r.mu.Lock()
+	r.calls = append(r.calls, u.String())
+	r.mu.Unlock()
+
+	if u.Path == "/broken" {
+		return renderResult{}, errors.New("browser crashed")
+	}
+
+	res := renderResult{statusCode: http.StatusOK, finalURL: u.String()}
+	if u.Path == "/spa" {
+		res.finalURL = u.JoinPath("..", "app").String()
+	}
+
+	if screenshot {
+		res.screenshot = renderedScreenshotsRoot + u.Host + u.Path + ".png"
+	}
+
+	return res, nil
+}
+
+func setRenderBackend(t *testing.T, r renderer) {
+	t.Helper()
+
+	prev := renderBackend
+	renderBackend = r
+
+	t.Cleanup(func() {
+		renderBackend = prev
+	})
+}
+
+func TestCrawlRender(t *testing.T) {
+	r := &fakeRenderer{}
+	setRenderBackend(t, r)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	urls := []string{srv.URL + "/spa", srv.URL + "/broken"}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:       urls,
+		Workers:    2,
+		TimeoutMS:  2000,
+		Render:     true,
+		Screenshot: true,
+	})
+
+	require.Len(t, got, len(urls))
+	require.Zero(t, hits.Load(), "rendered urls must not be fetched directly")
+
+	require.Equal(t, urls[0], got[0].URL)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.True(t, got[0].Success)
+	require.Equal(t, srv.URL+"/app", got[0].FinalURL)
+	require.Equal(t, renderedScreenshotsRoot+srv.Listener.Addr().String()+"/spa.png", got[0].Screenshot)
+
+	require.Equal(t, urls[1], got[1].URL)
+	require.Equal(t, errorCodeRenderFailed, got[1].ErrorCode)
+	require.False(t, got[1].Success)
+
+	// обычный обход того же урла идёт напрямую и не берёт результат рендеринга из кэша
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls[:1],
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Empty(t, got[0].FinalURL)
+	require.Empty(t, got[0].Screenshot)
+	require.EqualValues(t, 1, hits.Load())
+
+	// и наоборот: рендеринг не берёт обычный ответ из кэша
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls[:1],
+		Workers:   1,
+		TimeoutMS: 2000,
+		Render:    true,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, srv.URL+"/app", got[0].FinalURL)
+	require.Empty(t, got[0].Screenshot, "screenshot was not requested")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	require.Len(t, r.calls, 3)
+}
+
+func TestCrawlRenderHTTPBackend(t *testing.T) {
+	setRenderBackend(t, nil)
+
+	type renderCall struct {
+		URL        string `json:"url"`
+		Screenshot bool   `json:"screenshot"`
+		TimeoutMS  int    `json:"timeout_ms"`
+	}
+
+	calls := make(chan renderCall, 2)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call renderCall
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&call) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		calls <- call
+
+		if call.URL == "http://unreachable.test/" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentTypeJson)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"status_code": http.StatusNotFound,
+			"final_url":   call.URL + "#/not-found",
+			"screenshot":  "https://render.test/shots/1.png",
+		})
+	}))
+
+	t.Cleanup(backend.Close)
+	t.Setenv(renderURLEnv, backend.URL)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:       []string{"http://site.test/", "http://unreachable.test/"},
+		Workers:    1,
+		TimeoutMS:  3000,
+		Render:     true,
+		Screenshot: true,
+	})
+
+	require.Len(t, got, 2)
+
+	require.Equal(t, http.StatusNotFound, got[0].StatusCode)
+	require.False(t, got[0].Success)
+	require.Equal(t, "http://site.test/#/not-found", got[0].FinalURL)
+	require.Equal(t, "https://render.test/shots/1.png", got[0].Screenshot)
+
+	require.Equal(t, errorCodeRenderFailed, got[1].ErrorCode)
+
+	close(calls)
+	for call := range calls {
+		require.True(t, call.Screenshot)
+		require.Positive(t, call.TimeoutMS)
+		require.LessOrEqual(t, call.TimeoutMS, 3000)
+	}
+}
+
+func TestCrawlRenderNotConfigured(t *testing.T) {
+	setRenderBackend(t, nil)
+	t.Setenv(renderURLEnv, "")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	resp := postCrawl(t, client(), baseUrl, CrawlRequest{
+		URLs:      []string{"http://127.0.0.1/"},
+		Workers:   1,
+		TimeoutMS: 1000,
+		Render:    true,
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCrawlRenderValidation(t *testing.T) {
+	setRenderBackend(t, &fakeRenderer{})
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, req := range []CrawlRequest{
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, Screenshot: true},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, Render: true, Mode: "options"},
+	} {
+		resp := postCrawl(t, c, baseUrl, req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+}
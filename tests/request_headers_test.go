@@ -0,0 +1,133 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newHeaderEchoServer запоминает заголовки запросов по пути; /redirect ведёт на /final, /away - на target
+func newHeaderEchoServer(t *testing.T, target string) (srv *httptest.Server, seen func(path string) http.Header) {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		headers = make(map[string]http.Header)
+	)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers[r.URL.Path] = r.Header.Clone()
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		case "/away":
+			http.Redirect(w, r, target, http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv, func(path string) http.Header {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return headers[path]
+	}
+}
+
+func TestCrawlRequestHeaders(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	other, otherSeen := newHeaderEchoServer(t, "")
+	srv, seen := newHeaderEchoServer(t, other.URL+"/landing")
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/plain", srv.URL + "/redirect", srv.URL + "/away"},
+		Workers:   2,
+		TimeoutMS: 2000,
+		Headers: map[string]string{
+			"User-Agent":      "Mozilla/5.0 (test)",
+			"authorization":   "Bearer secret",
+			"Accept-Language": "ru",
+		},
+	})
+
+	require.Len(t, got, 3)
+	for _, r := range got {
+		require.Empty(t, r.Error, r.URL)
+		require.Equal(t, http.StatusNoContent, r.StatusCode, r.URL)
+	}
+
+	for _, path := range []string{"/plain", "/redirect", "/final", "/away"} {
+		h := seen(path)
+		require.NotNil(t, h, path)
+		require.Equal(t, "Mozilla/5.0 (test)", h.Get("User-Agent"), path)
+		require.Equal(t, "Bearer secret", h.Get("Authorization"), path)
+		require.Equal(t, "ru", h.Get("Accept-Language"), path)
+	}
+
+	// на чужой host:port учётные данные не уходят, остальные заголовки - да
+	h := otherSeen("/landing")
+	require.NotNil(t, h)
+	require.Empty(t, h.Get("Authorization"))
+	require.Equal(t, "Mozilla/5.0 (test)", h.Get("User-Agent"))
+	require.Equal(t, "ru", h.Get("Accept-Language"))
+}
+
+func TestCrawlDefaultUserAgent(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, seen := newHeaderEchoServer(t, "")
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/plain"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+	require.Equal(t, robotsUserAgent, seen("/plain").Get("User-Agent"))
+}
+
+func TestCrawlRequestHeadersValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, headers := range []map[string]string{
+		{"User-Agent": "a", "user-agent": "b"},
+		{"Bad Name": "x"},
+		{"X-Injected": "a\r\nX-Evil: 1"},
+		{"Host": "example.com"},
+		{"content-length": "10"},
+		{"Transfer-Encoding": "chunked"},
+		{"Connection": "close"},
+		{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	} {
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{"http://127.0.0.1/"},
+			Workers:   1,
+			TimeoutMS: 1000,
+			Headers:   headers,
+		})
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, headers)
+	}
+}
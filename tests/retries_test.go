@@ -0,0 +1,263 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyServer отвечает 503 на первые failures запросов, затем 200
+func newFlakyServer(t *testing.T, failures int64) (srv *httptest.Server, hits *atomic.Int64) {
+	t.Helper()
+
+	hits = &atomic.Int64{}
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv, hits
+}
+
+func TestCrawlRetriesWithBackoff(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, hits := newFlakyServer(t, 2)
+
+	const backoff = 10 * time.Second
+
+	result := crawlAsync(c, baseUrl, CrawlRequest{
+		URLs:           []string{srv.URL + "/flaky"},
+		Workers:        1,
+		TimeoutMS:      int((24 * time.Hour).Milliseconds()),
+		Retries:        3,
+		RetryBackoffMS: int(backoff.Milliseconds()),
+	})
+
+	require.Eventually(t, func() bool {
+		return hits.Load() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// повтор ждёт по clock: пока время стоит, второй попытки нет
+	require.Never(t, func() bool {
+		return hits.Load() > 1
+	}, 200*time.Millisecond, 10*time.Millisecond)
+
+	// задержка перед повтором k не больше backoff * 2^(k-1); по-настоящему не спим
+	for k := 1; k <= 2; k++ {
+		require.Eventually(t, func() bool {
+			if hits.Load() > int64(k) {
+				return true
+			}
+
+			clk.Advance(backoff << (k - 1))
+			return hits.Load() > int64(k)
+		}, time.Second, 5*time.Millisecond, "retry %d", k)
+
+		require.EqualValues(t, k+1, hits.Load())
+	}
+
+	var got []CrawlResponse
+	select {
+	case res := <-result:
+		require.NoError(t, res.err)
+		require.Equal(t, http.StatusOK, res.status)
+
+		got = res.results
+	case <-time.After(2 * time.Second):
+		t.Fatal("crawl did not finish after backoff elapsed")
+	}
+
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].Error)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.True(t, got[0].Success)
+	require.Equal(t, 3, got[0].Attempts)
+	require.EqualValues(t, 3, hits.Load())
+}
+
+func TestCrawlRetriesBackoffBounds(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	var (
+		mu    sync.Mutex
+		times []time.Time
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		n := len(times)
+		mu.Unlock()
+
+		if n <= 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	const (
+		backoff = 100 * time.Millisecond
+		slack   = 150 * time.Millisecond
+	)
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:           []string{srv.URL + "/flaky"},
+		Workers:        1,
+		TimeoutMS:      5000,
+		Retries:        3,
+		RetryBackoffMS: int(backoff.Milliseconds()),
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Equal(t, 4, got[0].Attempts)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.Len(t, times, 4)
+
+	for k := 1; k < len(times); k++ {
+		require.Less(t, times[k].Sub(times[k-1]), backoff<<(k-1)+slack, "delay before retry %d", k)
+	}
+}
+
+func TestCrawlRetriesExhausted(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, hits := newFlakyServer(t, 100)
+
+	var missingHits atomic.Int64
+	missing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		missingHits.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	t.Cleanup(missing.Close)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:           []string{srv.URL + "/down", missing.URL + "/gone", closedServerURL(t).JoinPath("refused").String()},
+		Workers:        3,
+		TimeoutMS:      5000,
+		Retries:        2,
+		RetryBackoffMS: 1,
+	})
+
+	require.Len(t, got, 3)
+
+	require.Equal(t, http.StatusServiceUnavailable, got[0].StatusCode)
+	require.Equal(t, 3, got[0].Attempts)
+	require.EqualValues(t, 3, hits.Load())
+
+	// 404 не временная ошибка
+	require.Equal(t, http.StatusNotFound, got[1].StatusCode)
+	require.Equal(t, 1, got[1].Attempts)
+	require.EqualValues(t, 1, missingHits.Load())
+
+	require.Equal(t, errorCodeFetchFailed, got[2].ErrorCode)
+	require.Equal(t, 3, got[2].Attempts)
+}
+
+func TestCrawlRetriesServerDefaults(t *testing.T) {
+	t.Setenv("CRAWLER_RETRIES", "1")
+	t.Setenv("CRAWLER_RETRY_BACKOFF_MS", "1")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, hits := newFlakyServer(t, 1)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/once"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Equal(t, 2, got[0].Attempts)
+	require.EqualValues(t, 2, hits.Load())
+}
+
+func TestCrawlRetriesValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, req := range []CrawlRequest{
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, Retries: -1},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, Retries: maxRetries + 1},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, Retries: 1, RetryBackoffMS: -1},
+	} {
+		resp := postCrawl(t, c, baseUrl, req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+
+	t.Setenv("CRAWLER_RETRIES", "often")
+
+	requireStartError(t, New())
+}
+
+func TestCrawlRetriesOption(t *testing.T) {
+	t.Setenv("CRAWLER_RETRIES", "0")
+
+	baseUrl, stopWait := serveCrawler(t.Context(), t, New(WithRetries(2, time.Millisecond)))
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, hits := newFlakyServer(t, 2)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/twice"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Equal(t, 3, got[0].Attempts, "option must take priority over CRAWLER_RETRIES")
+	require.EqualValues(t, 3, hits.Load())
+
+	// поле запроса по-прежнему важнее значения по умолчанию
+	srv, hits = newFlakyServer(t, 3)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/once"},
+		Workers:   1,
+		TimeoutMS: 2000,
+		Retries:   1,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusServiceUnavailable, got[0].StatusCode)
+	require.Equal(t, 2, got[0].Attempts)
+	require.EqualValues(t, 2, hits.Load())
+
+	requireStartError(t, New(WithRetries(maxRetries+1, 0)))
+}
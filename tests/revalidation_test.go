@@ -0,0 +1,200 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newRevalidationServer отвечает 200 на первый запрос, а на последующие - 201, задерживая их до закрытия gate
+func newRevalidationServer(t *testing.T) (srv *httptest.Server, hits *atomic.Int64, release func()) {
+	t.Helper()
+
+	hits = &atomic.Int64{}
+	gate := make(chan struct{})
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			close(gate)
+		})
+	}
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		select {
+		case <-gate:
+		case <-r.Context().Done():
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	t.Cleanup(func() {
+		release()
+		srv.Close()
+	})
+
+	return srv, hits, release
+}
+
+// expireEntry переводит часы так, чтобы запись, сохранённая сейчас, истекла, но не попала под фоновый проход
+func expireEntry(t *testing.T, c *http.Client, baseURL *url.URL, clk *fakeClock, target string) {
+	t.Helper()
+
+	clk.Advance(cacheTTL / 2)
+
+	got := crawl(t, c, baseURL, CrawlRequest{
+		URLs:      []string{target},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+
+	clk.Advance(cacheTTL - time.Millisecond)
+	clk.Advance(2 * time.Millisecond)
+}
+
+// startConcurrently запускает n одинаковых /crawl, не дожидаясь их
+func startConcurrently(c *http.Client, baseURL *url.URL, n int, req CrawlRequest) []<-chan crawlResult {
+	pending := make([]<-chan crawlResult, n)
+	for i := range pending {
+		pending[i] = crawlAsync(c, baseURL, req)
+	}
+
+	return pending
+}
+
+// awaitAll дожидается обходов из startConcurrently; у каждого ровно один результат
+func awaitAll(t *testing.T, pending []<-chan crawlResult) []CrawlResponse {
+	t.Helper()
+
+	results := make([]CrawlResponse, 0, len(pending))
+	for _, ch := range pending {
+		got := awaitCrawl(t, ch)
+		require.Len(t, got, 1)
+
+		results = append(results, got[0])
+	}
+
+	return results
+}
+
+func TestRevalidationCoalescedWait(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, hits, release := newRevalidationServer(t)
+
+	target := srv.URL + "/hot"
+	expireEntry(t, c, baseUrl, clk, target)
+
+	const n = 16
+	pending := startConcurrently(c, baseUrl, n, CrawlRequest{
+		URLs:      []string{target},
+		Workers:   1,
+		TimeoutMS: 5000,
+	})
+
+	require.Eventually(t, func() bool {
+		return hits.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// пока перепроверка висит, остальные запросы доходят до кэша и не идут в апстрим
+	require.Never(t, func() bool {
+		return hits.Load() > 2
+	}, 200*time.Millisecond, 10*time.Millisecond)
+
+	release()
+
+	results := awaitAll(t, pending)
+
+	require.Len(t, results, n)
+	for _, r := range results {
+		require.Equal(t, http.StatusCreated, r.StatusCode)
+		require.False(t, r.Stale)
+	}
+
+	require.EqualValues(t, 2, hits.Load())
+}
+
+func TestRevalidationServeStale(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, hits, release := newRevalidationServer(t)
+
+	target := srv.URL + "/hot"
+	expireEntry(t, c, baseUrl, clk, target)
+
+	req := CrawlRequest{
+		URLs:        []string{target},
+		Workers:     1,
+		TimeoutMS:   5000,
+		StalePolicy: "stale",
+	}
+
+	// первый запрос запускает перепроверку и повисает на ней
+	revalidated := crawlAsync(c, baseUrl, req)
+
+	require.Eventually(t, func() bool {
+		return hits.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	const n = 8
+	results := awaitAll(t, startConcurrently(c, baseUrl, n, req))
+
+	for _, r := range results {
+		require.Equal(t, http.StatusOK, r.StatusCode)
+		require.True(t, r.Stale)
+	}
+
+	require.EqualValues(t, 2, hits.Load())
+
+	release()
+
+	got := awaitCrawl(t, revalidated)
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusCreated, got[0].StatusCode)
+	require.False(t, got[0].Stale)
+
+	got = crawl(t, c, baseUrl, req)
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusCreated, got[0].StatusCode)
+	require.False(t, got[0].Stale)
+	require.EqualValues(t, 2, hits.Load())
+}
+
+func TestStalePolicyValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:        []string{"http://127.0.0.1/"},
+		Workers:     1,
+		TimeoutMS:   1000,
+		StalePolicy: "refresh",
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,152 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newRobotsMetaServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/both":
+			w.Header().Add("X-Robots-Tag", "NoIndex")
+			w.Header().Add("X-Robots-Tag", "otherbot: noarchive")
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+			_, _ = w.Write([]byte(`<html><head>
+<meta name="Robots" content="nofollow, noindex">
+<meta name="otherbot" content="nosnippet">
+</head><body>hello</body></html>`))
+		case "/late":
+			// meta за пределами первых 64 KiB не разбирается
+			w.Header().Set("Content-Type", "text/html")
+
+			_, _ = w.Write([]byte("<html><body>" + strings.Repeat("x", 128<<10)))
+			_, _ = w.Write([]byte(`<meta name="robots" content="noindex"></body></html>`))
+		case "/text":
+			w.Header().Set("Content-Type", "text/plain")
+
+			_, _ = w.Write([]byte(`<meta name="robots" content="noindex">`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRobotsMeta(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newRobotsMetaServer(t)
+
+	urls := []string{srv.URL + "/both", srv.URL + "/late", srv.URL + "/text", srv.URL + "/plain"}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:       urls,
+		Workers:    2,
+		TimeoutMS:  2000,
+		RobotsMeta: true,
+	})
+
+	require.Len(t, got, len(urls))
+
+	for i := range urls {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Empty(t, got[i].Error)
+		require.Equal(t, http.StatusOK, got[i].StatusCode)
+	}
+
+	require.Equal(t, []string{"noindex", "nofollow"}, got[0].Robots)
+	require.Empty(t, got[1].Robots)
+	require.Empty(t, got[2].Robots)
+	require.Empty(t, got[3].Robots)
+}
+
+func TestRobotsMetaDisabled(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newRobotsMetaServer(t)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/both"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Empty(t, got[0].Robots)
+}
+
+func TestRobotsMetaNoindexNofollow(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+
+		switch r.URL.Path {
+		case "/noindex":
+			_, _ = w.Write([]byte(`<html><head><meta name="robots" content="noindex"></head>
+<body><a href="/from-noindex">x</a></body></html>`))
+		case "/nofollow":
+			w.Header().Set("X-Robots-Tag", "nofollow")
+
+			_, _ = w.Write([]byte(`<html><body><a href="/from-nofollow">x</a></body></html>`))
+		default:
+			_, _ = w.Write([]byte(`<html><body>leaf</body></html>`))
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	req := CrawlRequest{
+		URLs:        []string{srv.URL + "/noindex", srv.URL + "/nofollow"},
+		Workers:     2,
+		TimeoutMS:   2000,
+		Depth:       1,
+		IncludeBody: true,
+	}
+
+	withoutMeta := crawl(t, c, baseUrl, req)
+
+	require.Len(t, withoutMeta, 4, "без robots_meta директивы не соблюдаются")
+	require.Contains(t, withoutMeta[0].Body, "from-noindex")
+	require.Equal(t, srv.URL+"/from-noindex", withoutMeta[2].URL)
+	require.Equal(t, srv.URL+"/from-nofollow", withoutMeta[3].URL)
+
+	req.RobotsMeta = true
+	got := crawl(t, c, baseUrl, req)
+
+	require.Len(t, got, 3)
+
+	require.Equal(t, srv.URL+"/noindex", got[0].URL)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Equal(t, []string{"noindex"}, got[0].Robots)
+	require.Empty(t, got[0].Body, "noindex не отдаёт содержимое")
+
+	require.Equal(t, srv.URL+"/nofollow", got[1].URL)
+	require.Equal(t, []string{"nofollow"}, got[1].Robots)
+	require.Contains(t, got[1].Body, "from-nofollow")
+
+	require.Equal(t, srv.URL+"/from-noindex", got[2].URL, "ссылки noindex-страницы берутся")
+	require.Equal(t, srv.URL+"/noindex", got[2].Parent)
+	require.Equal(t, 1, got[2].Depth)
+	require.Contains(t, got[2].Body, "leaf")
+}
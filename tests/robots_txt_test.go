@@ -0,0 +1,247 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	errorCodeRobotsDisallowed = "robots_disallowed"
+	robotsBlockedError        = "blocked by robots.txt"
+	quarantineReasonRobots    = "robots"
+)
+
+// newRobotsServer отдаёт robots.txt (или код robotsStatus, если он не 200) и 200 на остальные пути
+func newRobotsServer(t *testing.T, robotsStatus int, robots string) (srv *httptest.Server, robotsHits *atomic.Int64, paths func() []string) {
+	t.Helper()
+
+	robotsHits = &atomic.Int64{}
+
+	var (
+		mu      sync.Mutex
+		fetched []string
+	)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsHits.Add(1)
+
+			if robotsStatus != http.StatusOK {
+				w.WriteHeader(robotsStatus)
+				return
+			}
+
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(robots))
+			return
+		}
+
+		mu.Lock()
+		fetched = append(fetched, r.URL.RequestURI()+" "+r.UserAgent())
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	paths = func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string(nil), fetched...)
+	}
+
+	return srv, robotsHits, paths
+}
+
+func TestCrawlRespectRobots(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv, robotsHits, paths := newRobotsServer(t, http.StatusOK, strings.Join([]string{
+		"User-agent: *",
+		"Disallow: /private",
+		"Allow: /private/public",
+		"Disallow: /*.pdf$",
+		"",
+		"User-agent: googlebot",
+		"Disallow: /",
+	}, "\n"))
+
+	urls := []string{
+		srv.URL + "/",
+		srv.URL + "/private/x",
+		srv.URL + "/private/public/y",
+		srv.URL + "/docs/a.pdf",
+		srv.URL + "/docs/a.pdf?download=1",
+	}
+
+	req := CrawlRequest{
+		URLs:          urls,
+		Workers:       4,
+		TimeoutMS:     2000,
+		RespectRobots: true,
+	}
+
+	got := crawl(t, c, baseUrl, req)
+	require.Len(t, got, len(urls))
+
+	allowed := map[int]bool{0: true, 2: true, 4: true}
+	for i, r := range got {
+		require.Equal(t, urls[i], r.URL)
+
+		if allowed[i] {
+			require.Empty(t, r.Error, r.URL)
+			require.Equal(t, http.StatusOK, r.StatusCode, r.URL)
+			continue
+		}
+
+		require.Equal(t, robotsBlockedError, r.Error, r.URL)
+		require.Equal(t, errorCodeRobotsDisallowed, r.ErrorCode, r.URL)
+		require.Zero(t, r.StatusCode, r.URL)
+	}
+
+	require.ElementsMatch(t, []string{
+		"/ " + robotsUserAgent,
+		"/private/public/y " + robotsUserAgent,
+		"/docs/a.pdf?download=1 " + robotsUserAgent,
+	}, paths())
+
+	// правила общие для обходов и хранятся robotsTTL
+	req.URLs = []string{srv.URL + "/private/other", srv.URL + "/public"}
+	got = crawl(t, c, baseUrl, req)
+
+	require.Len(t, got, 2)
+	require.Equal(t, errorCodeRobotsDisallowed, got[0].ErrorCode)
+	require.Equal(t, http.StatusOK, got[1].StatusCode)
+	require.EqualValues(t, 1, robotsHits.Load())
+
+	// без respect_robots ограничений нет
+	req.URLs = []string{srv.URL + "/private/z"}
+	req.RespectRobots = false
+	got = crawl(t, c, baseUrl, req)
+
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].Error)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+}
+
+func TestCrawlRespectRobotsUserAgentGroup(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	srv, _, _ := newRobotsServer(t, http.StatusOK, strings.Join([]string{
+		"User-agent: *",
+		"Disallow: /",
+		"",
+		"User-agent: NetCrawler",
+		"Disallow: /admin",
+	}, "\n"))
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:          []string{srv.URL + "/page", srv.URL + "/admin/panel"},
+		Workers:       2,
+		TimeoutMS:     2000,
+		RespectRobots: true,
+	})
+
+	require.Len(t, got, 2)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Equal(t, errorCodeRobotsDisallowed, got[1].ErrorCode)
+}
+
+func TestCrawlRespectRobotsSingleFetch(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	srv, robotsHits, _ := newRobotsServer(t, http.StatusOK, "User-agent: *\nDisallow: /nope\n")
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:          makeURLs(t, srv.URL, 50),
+		Workers:       16,
+		TimeoutMS:     5000,
+		RespectRobots: true,
+	})
+
+	require.Len(t, got, 50)
+	require.EqualValues(t, 1, robotsHits.Load())
+}
+
+func TestCrawlRespectRobotsUnavailable(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	missing, _, missingPaths := newRobotsServer(t, http.StatusNotFound, "")
+	broken, brokenRobots, brokenPaths := newRobotsServer(t, http.StatusServiceUnavailable, "")
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:          []string{missing.URL + "/a", broken.URL + "/b"},
+		Workers:       2,
+		TimeoutMS:     2000,
+		RespectRobots: true,
+	})
+
+	require.Len(t, got, 2)
+
+	// 4xx - ограничений нет
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Len(t, missingPaths(), 1)
+
+	// 5xx - запрещён весь хост
+	require.Equal(t, errorCodeRobotsDisallowed, got[1].ErrorCode)
+	require.Empty(t, brokenPaths())
+
+	brokenURL, err := url.Parse(broken.URL)
+	require.NoError(t, err)
+
+	var entry *quarantineEntry
+	for _, e := range getQuarantine(t, c, baseUrl) {
+		if e.Host == brokenURL.Host {
+			entry = &e
+		}
+	}
+
+	require.NotNil(t, entry)
+	require.Equal(t, quarantineReasonRobots, entry.Reason)
+	require.True(t, clk.Now().Add(robotsTTL).Equal(entry.Until), "until %s", entry.Until)
+
+	// снятие записи сбрасывает правила, robots.txt загружается заново
+	require.Equal(t, http.StatusNoContent, liftQuarantine(t, c, baseUrl, brokenURL.Host))
+
+	crawl(t, c, baseUrl, CrawlRequest{
+		URLs:          []string{broken.URL + "/c"},
+		Workers:       1,
+		TimeoutMS:     2000,
+		RespectRobots: true,
+	})
+
+	require.EqualValues(t, 2, brokenRobots.Load())
+
+	// после robotsTTL правила тоже загружаются заново
+	clk.Advance(robotsTTL + time.Second)
+
+	crawl(t, c, baseUrl, CrawlRequest{
+		URLs:          []string{broken.URL + "/d"},
+		Workers:       1,
+		TimeoutMS:     2000,
+		RespectRobots: true,
+	})
+
+	require.EqualValues(t, 3, brokenRobots.Load())
+}
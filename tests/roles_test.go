@@ -0,0 +1,165 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	roleReader    = "reader"
+	roleSubmitter = "submitter"
+	roleAdmin     = "admin"
+)
+
+// setAPIKeyRoles включает проверку ролей. Вызывать до старта краулера.
+func setAPIKeyRoles(t *testing.T, roles map[string]string) {
+	t.Helper()
+
+	prev := apiKeyRoles
+	apiKeyRoles = roles
+
+	t.Cleanup(func() {
+		apiKeyRoles = prev
+	})
+}
+
+func getWithKey(t *testing.T, c *http.Client, target *url.URL, key string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	require.NoError(t, err)
+
+	if key != "" {
+		req.Header.Set(apiKeyHeader, key)
+	}
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	return resp
+}
+
+func TestRolesAccess(t *testing.T) {
+	setAPIKeyRoles(t, map[string]string{
+		"r": roleReader,
+		"s": roleSubmitter,
+		"a": roleAdmin,
+	})
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL + "/200"},
+		Workers:   1,
+		TimeoutMS: 1000,
+	}
+
+	submit := map[string]*url.URL{
+		"crawl": constructCrawlPath(t, baseUrl),
+		"jobs":  constructJobsPath(t, baseUrl),
+	}
+
+	for name, target := range submit {
+		require.Equal(t, http.StatusUnauthorized, postWithKey(t, c, target, "", req).StatusCode, name)
+		require.Equal(t, http.StatusUnauthorized, postWithKey(t, c, target, "unknown", req).StatusCode, name)
+		require.Equal(t, http.StatusForbidden, postWithKey(t, c, target, "r", req).StatusCode, name)
+	}
+
+	require.Equal(t, http.StatusOK, postWithKey(t, c, submit["crawl"], "s", req).StatusCode)
+	require.Equal(t, http.StatusOK, postWithKey(t, c, submit["crawl"], "a", req).StatusCode)
+
+	resp := postWithKey(t, c, submit["jobs"], "s", req)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var job struct {
+		ID string `json:"id"`
+	}
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+
+	events := constructJobsPath(t, baseUrl, job.ID, "events")
+	require.Equal(t, http.StatusUnauthorized, getWithKey(t, c, events, "").StatusCode)
+
+	for _, key := range []string{"r", "s", "a"} {
+		require.Equal(t, http.StatusOK, getWithKey(t, c, events, key).StatusCode, key)
+	}
+
+	debug := baseUrl.JoinPath(recentErrorsPath)
+	require.Equal(t, http.StatusForbidden, getWithKey(t, c, debug, "r").StatusCode)
+	require.Equal(t, http.StatusForbidden, getWithKey(t, c, debug, "s").StatusCode)
+	require.Equal(t, http.StatusOK, getWithKey(t, c, debug, "a").StatusCode)
+}
+
+func TestRolesGraphQLMutation(t *testing.T) {
+	setAPIKeyRoles(t, map[string]string{
+		"r": roleReader,
+		"s": roleSubmitter,
+	})
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	query := map[string]any{
+		"query":     `mutation($urls: [String!]!) { crawl(urls: $urls, workers: 1, timeoutMs: 1000) { statusCode } }`,
+		"variables": map[string]any{"urls": []string{srv.URL + "/200"}},
+	}
+
+	var gql struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Extensions struct {
+				Code string `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+
+	resp := postWithKey(t, c, baseUrl.JoinPath(graphqlPath), "r", query)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&gql))
+	require.Equal(t, "null", string(gql.Data))
+	require.Len(t, gql.Errors, 1)
+	require.Equal(t, "forbidden", gql.Errors[0].Extensions.Code)
+
+	resp = postWithKey(t, c, baseUrl.JoinPath(graphqlPath), "s", query)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	gql.Errors = nil
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&gql))
+	require.Empty(t, gql.Errors)
+}
+
+func TestRolesDisabled(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/200"},
+		Workers:   1,
+		TimeoutMS: 1000,
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = getWithKey(t, c, baseUrl.JoinPath(recentErrorsPath), "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
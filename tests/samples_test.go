@@ -0,0 +1,130 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawlSamplesPerURL(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var (
+		hits  atomic.Int64
+		conns atomic.Int64
+	)
+
+	// балансировщик с одним больным бэкендом из двух
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stable" || hits.Add(1)%2 == 1 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	const (
+		samples  = 4
+		interval = 50 * time.Millisecond
+	)
+
+	start := time.Now()
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:             []string{srv.URL + "/flaky", srv.URL + "/stable"},
+		Workers:          2,
+		TimeoutMS:        5000,
+		SamplesPerURL:    samples,
+		SampleIntervalMS: int(interval.Milliseconds()),
+	})
+	elapsed := time.Since(start)
+
+	require.Len(t, got, 2)
+
+	flaky := got[0]
+	require.NotNil(t, flaky.Samples)
+	require.Equal(t, samples, flaky.Samples.Count)
+	require.Equal(t, map[string]int{"200": samples / 2, "503": samples / 2}, flaky.Samples.Statuses)
+	require.True(t, flaky.Samples.Flaky)
+	require.False(t, flaky.Success)
+	// при равенстве - последний итог, а последним был 503
+	require.Equal(t, http.StatusServiceUnavailable, flaky.StatusCode)
+	require.LessOrEqual(t, flaky.Samples.MinMS, flaky.Samples.MedianMS)
+	require.LessOrEqual(t, flaky.Samples.MedianMS, flaky.Samples.MaxMS)
+
+	stable := got[1]
+	require.NotNil(t, stable.Samples)
+	require.Equal(t, map[string]int{"200": samples}, stable.Samples.Statuses)
+	require.False(t, stable.Samples.Flaky)
+	require.True(t, stable.Success)
+	require.Equal(t, http.StatusOK, stable.StatusCode)
+
+	// каждый замер - новое соединение, урлы параллельно, а замеры одного урла - с паузой
+	require.GreaterOrEqual(t, conns.Load(), int64(2*samples))
+	require.GreaterOrEqual(t, elapsed, (samples-1)*interval)
+	require.Less(t, elapsed, 2*(samples-1)*interval+time.Second)
+
+	// замеры не кэшируются
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:          []string{srv.URL + "/flaky"},
+		Workers:       1,
+		TimeoutMS:     5000,
+		SamplesPerURL: samples,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, samples, got[0].Samples.Count)
+	require.EqualValues(t, 2*samples, hits.Load())
+}
+
+func TestCrawlSingleSampleHasNoDistribution(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	srv := newStatusServer(t)
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:          []string{srv.URL + "/200"},
+		Workers:       1,
+		TimeoutMS:     2000,
+		SamplesPerURL: 1,
+	})
+
+	require.Len(t, got, 1)
+	require.Nil(t, got[0].Samples)
+}
+
+func TestCrawlSamplesValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, req := range []CrawlRequest{
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, SamplesPerURL: -1},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, SamplesPerURL: maxSamplesPerURL + 1},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, SamplesPerURL: 2, SampleIntervalMS: -1},
+	} {
+		resp := postCrawl(t, c, baseUrl, req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+}
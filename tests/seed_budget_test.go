@@ -0,0 +1,115 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type seedTotals struct {
+	URL      string `json:"url"`
+	Pages    int    `json:"pages"`
+	Dropped  int    `json:"dropped"`
+	MaxDepth int    `json:"max_depth"`
+}
+
+func TestRecursiveCrawlSeedBudget(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	const dense = 10
+
+	// newSeedSite: /dense ссылается на dense страниц, /b - цепочка из двух страниц со ссылкой на /dense/0
+	newSeedSite := func() *httptest.Server {
+		srv, _ := newSiteServer(t, func(string) map[string]string {
+			site := map[string]string{
+				"/b":   links("/b/1"),
+				"/b/1": links("/b/2", "/dense/0"),
+				"/b/2": links(),
+			}
+
+			hrefs := make([]string, dense)
+			for i := range hrefs {
+				hrefs[i] = "/dense/" + strconv.Itoa(i)
+				site[hrefs[i]] = links()
+			}
+
+			site["/dense"] = links(hrefs...)
+			return site
+		})
+
+		return srv
+	}
+
+	srv := newSeedSite()
+
+	req := CrawlRequest{
+		URLs:            []string{srv.URL + "/dense", srv.URL + "/b"},
+		Workers:         4,
+		TimeoutMS:       5000,
+		Depth:           2,
+		MaxPagesPerSeed: 3,
+	}
+
+	got := crawl(t, c, baseUrl, req)
+	require.GreaterOrEqual(t, len(got), 2)
+
+	perSeed := make(map[string]int)
+	for _, r := range got[2:] {
+		require.NotEmpty(t, r.SeedURL, r.URL)
+		perSeed[r.SeedURL]++
+	}
+
+	require.Empty(t, got[0].SeedURL)
+	require.Empty(t, got[1].SeedURL)
+
+	// /dense/0 уже найдена от /dense и seed /b её не забирает
+	require.Equal(t, map[string]int{srv.URL + "/dense": 3, srv.URL + "/b": 2}, perSeed)
+
+	for _, r := range got[2:] {
+		if r.URL == srv.URL+"/b/2" {
+			require.Equal(t, 2, r.Depth)
+			require.Equal(t, srv.URL+"/b/1", r.Parent)
+		}
+	}
+
+	// задача обходит новый сайт, чтобы не зависеть от того, что кэш хранит для рекурсии
+	srv = newSeedSite()
+	req.URLs = []string{srv.URL + "/dense", srv.URL + "/b"}
+
+	events := waitJob(t, c, baseUrl, submitJob(t, c, baseUrl, req))
+	require.NotEmpty(t, events)
+
+	var done struct {
+		Seeds []seedTotals `json:"seeds"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(events[len(events)-1].Data), &done))
+	require.Equal(t, []seedTotals{
+		{URL: srv.URL + "/dense", Pages: 3, Dropped: dense - 3, MaxDepth: 1},
+		{URL: srv.URL + "/b", Pages: 2, Dropped: 0, MaxDepth: 2},
+	}, done.Seeds)
+}
+
+func TestRecursiveCrawlSeedBudgetValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, req := range []CrawlRequest{
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, Depth: 1, MaxPagesPerSeed: -1},
+		{URLs: []string{"http://127.0.0.1/"}, Workers: 1, TimeoutMS: 1000, MaxPagesPerSeed: 5},
+	} {
+		resp := postCrawl(t, c, baseUrl, req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+}
@@ -0,0 +1,252 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const crawlSeedHeader = "X-Crawl-Seed"
+
+func TestSeedReplaysShuffle(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+
+	srv := newOrderRecordingServer(t, &order, &mu)
+
+	const n = 30
+
+	// порядок отправки в виде позиций во входном списке; урлы новые в каждом прогоне, чтобы не попасть в кэш
+	run := 0
+	dispatchOrder := func(seed int64) (indexes []int, reported int64) {
+		run++
+		prefix := srv.URL + "/run" + strconv.Itoa(run)
+		urls := makeURLs(t, prefix, n)
+
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{
+			URLs:      urls,
+			Workers:   1,
+			TimeoutMS: 5000,
+			Shuffle:   true,
+			Seed:      seed,
+		})
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		reported, err := strconv.ParseInt(resp.Header.Get(crawlSeedHeader), 10, 64)
+		require.NoError(t, err)
+		require.NotZero(t, reported)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, u := range order {
+			if !strings.HasPrefix(u, prefix+"/") {
+				continue
+			}
+
+			indexes = append(indexes, slices.Index(urls, u))
+		}
+
+		require.Len(t, indexes, n)
+		return indexes, reported
+	}
+
+	first, seed := dispatchOrder(0)
+
+	replayed, reported := dispatchOrder(seed)
+	require.Equal(t, seed, reported)
+	require.Equal(t, first, replayed)
+
+	again, _ := dispatchOrder(seed)
+	require.Equal(t, first, again)
+
+	other, reported := dispatchOrder(seed + 1)
+	require.Equal(t, seed+1, reported)
+	require.NotEqual(t, first, other)
+}
+
+// pendingBelow ждёт, пока на clk не окажется ровно n ожиданий короче limit, и возвращает их по возрастанию.
+// Остальные ожидания (таймауты обхода) не учитываются
+func pendingBelow(t *testing.T, clk *fakeClock, n int, limit time.Duration) []time.Duration {
+	t.Helper()
+
+	collect := func() []time.Duration {
+		var pending []time.Duration
+		for _, d := range clk.Pending() {
+			if d < limit {
+				pending = append(pending, d)
+			}
+		}
+
+		return pending
+	}
+
+	require.Eventually(t, func() bool {
+		return len(collect()) == n
+	}, 2*time.Second, 10*time.Millisecond)
+
+	pending := collect()
+	slices.Sort(pending)
+
+	return pending
+}
+
+func TestSeedReplaysDispatchJitter(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	const (
+		n      = 8
+		jitter = 500 * time.Millisecond
+	)
+
+	run := 0
+	delays := func(seed int64) []time.Duration {
+		run++
+		urls := makeURLs(t, srv.URL+"/run"+strconv.Itoa(run), n)
+
+		done := crawlAsync(c, baseUrl, CrawlRequest{
+			URLs:             urls,
+			Workers:          n,
+			TimeoutMS:        5000,
+			DispatchJitterMS: int(jitter.Milliseconds()),
+			Seed:             seed,
+		})
+
+		pending := pendingBelow(t, clk, n, jitter)
+
+		clk.Advance(jitter)
+		require.Len(t, awaitCrawl(t, done), n)
+
+		return pending
+	}
+
+	const seed = 7
+
+	first := delays(seed)
+	require.Equal(t, first, delays(seed))
+	require.NotEqual(t, first, delays(seed+1))
+}
+
+func TestSeedReplaysRetryJitter(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	// первая попытка каждого урла - 503, повтор - 200
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]bool)
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		retry := seen[r.URL.Path]
+		seen[r.URL.Path] = true
+		mu.Unlock()
+
+		if !retry {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	const (
+		n       = 8
+		backoff = 500 * time.Millisecond
+	)
+
+	run := 0
+	delays := func(seed int64) []time.Duration {
+		run++
+		urls := makeURLs(t, srv.URL+"/run"+strconv.Itoa(run), n)
+
+		done := crawlAsync(c, baseUrl, CrawlRequest{
+			URLs:           urls,
+			Workers:        n,
+			TimeoutMS:      60_000,
+			Retries:        1,
+			RetryBackoffMS: int(backoff.Milliseconds()),
+			Seed:           seed,
+		})
+
+		// full jitter: задержка перед первым повтором не больше backoff
+		pending := pendingBelow(t, clk, n, backoff+time.Millisecond)
+
+		clk.Advance(backoff)
+
+		got := awaitCrawl(t, done)
+		require.Len(t, got, n)
+
+		for _, r := range got {
+			require.Equal(t, http.StatusOK, r.StatusCode)
+			require.Equal(t, 2, r.Attempts)
+		}
+
+		return pending
+	}
+
+	const seed = 11
+
+	first := delays(seed)
+	require.Equal(t, first, delays(seed))
+	require.NotEqual(t, first, delays(seed+1))
+}
+
+func TestJobReportsSeed(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	for _, seed := range []int64{0, 123} {
+		events := waitJob(t, c, baseUrl, submitJob(t, c, baseUrl, CrawlRequest{
+			URLs:      makeURLs(t, srv.URL+"/job"+strconv.FormatInt(seed, 10), 2),
+			Workers:   1,
+			TimeoutMS: 2000,
+			Seed:      seed,
+		}))
+
+		require.NotEmpty(t, events)
+
+		var done struct {
+			Seed int64 `json:"seed"`
+		}
+
+		require.NoError(t, json.Unmarshal([]byte(events[len(events)-1].Data), &done))
+		require.NotZero(t, done.Seed)
+
+		if seed != 0 {
+			require.Equal(t, seed, done.Seed)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeenSetNoFalseNegatives(t *testing.T) {
+	const n = 100_000
+
+	s, err := newSeenSet(n, 0.01)
+	require.NoError(t, err)
+
+	for i := range n {
+		require.True(t, s.add(fmt.Sprintf("http://example.com/item-%d", i)))
+	}
+
+	for i := range n {
+		require.False(t, s.add(fmt.Sprintf("http://example.com/item-%d", i)))
+	}
+}
+
+// exactCheckShare добавляет n ключей в множество на n элементов и возвращает долю новых ключей,
+// для которых фильтр сработал ложно и понадобилось точное множество. Сами новые ключи множество
+// должно признать новыми
+func exactCheckShare(t *testing.T, n int, rate float64) float64 {
+	t.Helper()
+
+	s, err := newSeenSet(n, rate)
+	require.NoError(t, err)
+
+	for i := range n {
+		s.add(fmt.Sprintf("http://example.com/seen-%d", i))
+	}
+
+	before := s.exactChecks()
+
+	for i := range n {
+		require.True(t, s.add(fmt.Sprintf("http://example.com/unseen-%d", i)), "unique key dropped")
+	}
+
+	return float64(s.exactChecks()-before) / float64(n)
+}
+
+func TestSeenSetExactFallback(t *testing.T) {
+	const n = 100_000
+
+	// при n = 100_000 стандартное отклонение доли - доли процента от rate, запаса в 2 раза хватает с избытком
+	coarse := exactCheckShare(t, n, 0.05)
+	fine := exactCheckShare(t, n, 0.001)
+
+	require.LessOrEqual(t, coarse, 2*0.05)
+	require.LessOrEqual(t, fine, 2*0.001)
+
+	// точность действительно управляет фильтром, а не игнорируется
+	require.Greater(t, coarse, 0.01)
+	require.Less(t, fine, coarse/10)
+}
+
+func TestSeenSetOverfilledStaysExact(t *testing.T) {
+	// фильтр на 100 элементов после 10_000 отвечает «возможно, встречался» почти всегда,
+	// и результат определяет точное множество
+	s, err := newSeenSet(100, 0.01)
+	require.NoError(t, err)
+
+	for i := range 10_000 {
+		require.True(t, s.add(fmt.Sprintf("http://example.com/item-%d", i)))
+	}
+
+	for i := range 10_000 {
+		require.False(t, s.add(fmt.Sprintf("http://example.com/item-%d", i)))
+	}
+
+	require.Greater(t, s.exactChecks(), 10_000)
+}
+
+// heapInUse возвращает объём живой кучи после сборки мусора
+func heapInUse() uint64 {
+	runtime.GC()
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	return m.HeapAlloc
+}
+
+func TestSeenSetMemory(t *testing.T) {
+	const n = 100_000
+
+	padding := strings.Repeat("x", 512)
+
+	before := heapInUse()
+
+	s, err := newSeenSet(n, 0.001)
+	require.NoError(t, err)
+
+	for i := range n {
+		s.add(fmt.Sprintf("http://example.com/%s/%d", padding, i))
+	}
+
+	after := heapInUse()
+	runtime.KeepAlive(s)
+
+	// урлы по ~540 байт заняли бы больше 50 МБ, фильтр на 0.1% и 16-байтные отпечатки - порядка 2-4 МБ
+	require.Less(t, int64(after)-int64(before), int64(n*64), "memory must not depend on url length")
+}
+
+func TestRecursiveCrawlSeenSetDedup(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	// страницы ссылаются друг на друга и на себя, /shared доступна с каждой
+	srv, visited := newSiteServer(t, func(string) map[string]string {
+		return map[string]string{
+			"/":       links("/a", "/b", "/c"),
+			"/a":      links("/", "/b", "/c", "/shared", "/a#top"),
+			"/b":      links("/", "/a", "/c", "/shared"),
+			"/c":      links("/", "/a", "/b", "/shared"),
+			"/shared": links("/", "/a", "/b", "/c"),
+		}
+	})
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:                  []string{srv.URL + "/"},
+		Workers:               4,
+		TimeoutMS:             5000,
+		Depth:                 3,
+		SeenFalsePositiveRate: 1e-6,
+	})
+
+	urls := make([]string, len(got))
+	for i, r := range got {
+		urls[i] = r.URL
+	}
+
+	require.ElementsMatch(t, []string{
+		srv.URL + "/", srv.URL + "/a", srv.URL + "/b", srv.URL + "/c", srv.URL + "/shared",
+	}, urls)
+
+	require.ElementsMatch(t, []string{"/", "/a", "/b", "/c", "/shared"}, visited())
+}
+
+func TestSeenSetInvalidParams(t *testing.T) {
+	for _, tc := range []struct {
+		expected int
+		rate     float64
+	}{
+		{expected: 0, rate: 0.01},
+		{expected: -1, rate: 0.01},
+		{expected: 10, rate: 0},
+		{expected: 10, rate: 1},
+		{expected: 10, rate: -0.5},
+	} {
+		_, err := newSeenSet(tc.expected, tc.rate)
+		require.Error(t, err, "expected=%d rate=%f", tc.expected, tc.rate)
+	}
+}
+
+func TestCrawlInvalidSeenFalsePositiveRate(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, rate := range []float64{-0.1, 1, 2} {
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{
+			URLs:                  []string{"http://example.com"},
+			Workers:               1,
+			TimeoutMS:             1000,
+			SeenFalsePositiveRate: rate,
+		})
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "rate %f", rate)
+	}
+}
@@ -0,0 +1,108 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newOrderRecordingServer запоминает порядок, в котором к нему приходят запросы
+func newOrderRecordingServer(t *testing.T, order *[]string, mu *sync.Mutex) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*order = append(*order, "http://"+r.Host+r.URL.Path)
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCrawlShuffle(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+
+	first := newOrderRecordingServer(t, &order, &mu)
+	second := newOrderRecordingServer(t, &order, &mu)
+
+	const n = 25
+
+	// список отсортирован по хосту
+	urls := append(makeURLs(t, first.URL, n), makeURLs(t, second.URL, n)...)
+
+	req := CrawlRequest{
+		URLs:      urls,
+		Workers:   1,
+		TimeoutMS: 5000,
+		Shuffle:   true,
+	}
+
+	got := crawl(t, c, baseUrl, req)
+
+	require.Len(t, got, len(urls))
+	for i := range urls {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Equal(t, http.StatusNoContent, got[i].StatusCode)
+	}
+
+	mu.Lock()
+	firstOrder := slices.Clone(order)
+	order = order[:0]
+	mu.Unlock()
+
+	require.ElementsMatch(t, urls, firstOrder)
+	require.NotEqual(t, urls, firstOrder)
+
+	// без перемешивания был бы ровно один переход между хостами
+	fromFirst := func(u string) bool {
+		return strings.HasPrefix(u, first.URL+"/")
+	}
+
+	switches := 0
+	for i := 1; i < len(firstOrder); i++ {
+		if fromFirst(firstOrder[i]) != fromFirst(firstOrder[i-1]) {
+			switches++
+		}
+	}
+
+	require.Greater(t, switches, 5)
+
+	permutation := func(urls, order []string) []int {
+		perm := make([]int, len(order))
+		for i, u := range order {
+			perm[i] = slices.Index(urls, u)
+		}
+
+		return perm
+	}
+
+	// следующий обход - новая перестановка
+	again := append(makeURLs(t, first.URL+"/again", n), makeURLs(t, second.URL+"/again", n)...)
+
+	req.URLs = again
+	crawl(t, c, baseUrl, req)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	require.ElementsMatch(t, again, order)
+	require.NotEqual(t, permutation(urls, firstOrder), permutation(again, order))
+}
@@ -0,0 +1,249 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sitemapPath = "/crawl/sitemap"
+
+type sitemapRequest struct {
+	SitemapURL string   `json:"sitemap_url,omitempty"`
+	URLs       []string `json:"urls,omitempty"`
+	Workers    int      `json:"workers"`
+	TimeoutMS  int      `json:"timeout_ms"`
+}
+
+func urlset(locs ...string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+
+	for _, l := range locs {
+		fmt.Fprintf(&b, "<url><loc>%s</loc></url>", l)
+	}
+
+	b.WriteString("</urlset>")
+	return b.String()
+}
+
+func sitemapIndex(locs ...string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+
+	for _, l := range locs {
+		fmt.Fprintf(&b, "<sitemap><loc>%s</loc></sitemap>", l)
+	}
+
+	b.WriteString("</sitemapindex>")
+	return b.String()
+}
+
+func newSitemapServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		base := srv.URL
+
+		switch r.URL.Path {
+		case "/index.xml":
+			_, _ = w.Write([]byte(sitemapIndex(base+"/a.xml", base+"/missing.xml", base+"/b.xml.gz", base+"/nested.xml")))
+		case "/nested.xml":
+			_, _ = w.Write([]byte(sitemapIndex(base + "/c.xml")))
+		case "/a.xml":
+			_, _ = w.Write([]byte(urlset(base+"/page/1", base+"/page/2")))
+		case "/b.xml.gz":
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			_, _ = zw.Write([]byte(urlset(base+"/page/2", base+"/page/3")))
+			_ = zw.Close()
+
+			w.Header().Set("Content-Type", "application/gzip")
+			_, _ = w.Write(buf.Bytes())
+		case "/c.xml":
+			_, _ = w.Write([]byte(urlset(base + "/page/4")))
+		case "/deep.xml":
+			_, _ = w.Write([]byte(sitemapIndex(base+"/a.xml", base+"/deep1.xml")))
+		case "/deep1.xml":
+			_, _ = w.Write([]byte(sitemapIndex(base+"/c.xml", base+"/deep2.xml")))
+		case "/deep2.xml":
+			_, _ = w.Write([]byte(sitemapIndex(base + "/b.xml.gz")))
+		case "/wide.xml":
+			// первая и последняя (сверх лимита) ссылки ведут на непустые sitemap, остальные - на пустые
+			locs := make([]string, maxSitemapChildren+1)
+			for i := range locs {
+				locs[i] = base + "/wide/" + strconv.Itoa(i) + ".xml"
+			}
+
+			locs[0] = base + "/a.xml"
+			locs[maxSitemapChildren] = base + "/c.xml"
+
+			_, _ = w.Write([]byte(sitemapIndex(locs...)))
+		case "/empty.xml":
+			_, _ = w.Write([]byte(urlset()))
+		case "/not-sitemap.xml":
+			_, _ = w.Write([]byte("<html></html>"))
+		case "/page/1", "/page/2", "/page/3", "/page/4":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			if strings.HasPrefix(r.URL.Path, "/wide/") {
+				_, _ = w.Write([]byte(urlset()))
+				return
+			}
+
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func postSitemap(t *testing.T, c *http.Client, baseURL *url.URL, req sitemapRequest) *http.Response {
+	t.Helper()
+
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	resp, err := c.Post(baseURL.JoinPath(sitemapPath).String(), contentTypeJson, bytes.NewReader(body))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	return resp
+}
+
+func TestCrawlSitemapIndex(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newSitemapServer(t)
+
+	resp := postSitemap(t, c, baseUrl, sitemapRequest{
+		SitemapURL: srv.URL + "/index.xml",
+		Workers:    2,
+		TimeoutMS:  3000,
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got []CrawlResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	want := []string{srv.URL + "/page/1", srv.URL + "/page/2", srv.URL + "/page/3", srv.URL + "/page/4"}
+	require.Len(t, got, len(want))
+
+	for i := range want {
+		require.Equal(t, want[i], got[i].URL)
+		require.Equal(t, http.StatusNoContent, got[i].StatusCode)
+	}
+}
+
+func TestJobSitemapIndexSource(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newSitemapServer(t)
+
+	events := waitJob(t, c, baseUrl, submitJob(t, c, baseUrl, CrawlRequest{
+		Sitemaps:  []string{srv.URL + "/index.xml"},
+		Workers:   2,
+		TimeoutMS: 3000,
+	}))
+
+	require.NotEmpty(t, events)
+
+	var done struct {
+		Sources []sourceSummary `json:"sources"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(events[len(events)-1].Data), &done))
+	require.Len(t, done.Sources, 1)
+	require.Equal(t, "sitemap", done.Sources[0].Kind)
+	// urls считает все прочитанные записи, включая повтор page/2
+	require.Equal(t, 5, done.Sources[0].URLs)
+	require.Equal(t, 1, done.Sources[0].Duplicates)
+}
+
+func TestCrawlSitemapIndexLimits(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newSitemapServer(t)
+
+	sitemapURLs := func(sitemap string) []string {
+		t.Helper()
+
+		resp := postSitemap(t, c, baseUrl, sitemapRequest{SitemapURL: srv.URL + sitemap, Workers: 4, TimeoutMS: 10_000})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var got []CrawlResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+		urls := make([]string, len(got))
+		for i, r := range got {
+			urls[i] = r.URL
+		}
+
+		return urls
+	}
+
+	// deep.xml - уровень 1, deep1.xml - 2, deep2.xml - 3 и уже не читается
+	require.Equal(t, []string{srv.URL + "/page/1", srv.URL + "/page/2", srv.URL + "/page/4"}, sitemapURLs("/deep.xml"))
+
+	// ссылка номер maxSitemapChildren+1 пропускается
+	require.Equal(t, []string{srv.URL + "/page/1", srv.URL + "/page/2"}, sitemapURLs("/wide.xml"))
+}
+
+func TestCrawlSitemapErrors(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newSitemapServer(t)
+
+	resp := postSitemap(t, c, baseUrl, sitemapRequest{SitemapURL: srv.URL + "/empty.xml", Workers: 1, TimeoutMS: 1000})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got []CrawlResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Empty(t, got)
+
+	for _, path := range []string{"/missing.xml", "/not-sitemap.xml"} {
+		resp := postSitemap(t, c, baseUrl, sitemapRequest{SitemapURL: srv.URL + path, Workers: 1, TimeoutMS: 1000})
+		require.Equal(t, http.StatusBadGateway, resp.StatusCode, path)
+
+		var body struct {
+			Error string `json:"error"`
+		}
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.NotEmpty(t, body.Error, path)
+	}
+
+	for _, req := range []sitemapRequest{
+		{Workers: 1, TimeoutMS: 1000},
+		{SitemapURL: srv.URL + "/a.xml", URLs: []string{srv.URL + "/page/1"}, Workers: 1, TimeoutMS: 1000},
+		{SitemapURL: srv.URL + "/a.xml", Workers: 0, TimeoutMS: 1000},
+	} {
+		resp := postSitemap(t, c, baseUrl, req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+}
@@ -0,0 +1,157 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sourceSummary struct {
+	Kind       string `json:"kind"`
+	Source     string `json:"source"`
+	URLs       int    `json:"urls"`
+	Duplicates int    `json:"duplicates"`
+	Error      string `json:"error"`
+}
+
+func newSourcesServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/list.txt":
+			fmt.Fprintf(w, "%[1]s/page/1\n\n# comment\n  %[1]s/page/2  \n%[1]s/page/3\n", srv.URL)
+		case "/sitemap.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>%[1]s/page/3</loc></url>
+  <url><loc>%[1]s/page/4</loc><lastmod>2025-01-01</lastmod></url>
+</urlset>`, srv.URL)
+		case "/page/1", "/page/2", "/page/3", "/page/4", "/page/inline":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMultiSourceJob(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newSourcesServer(t)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/page/inline", srv.URL + "/page/1"},
+		URLLists:  []string{srv.URL + "/list.txt", srv.URL + "/missing.txt"},
+		Sitemaps:  []string{srv.URL + "/sitemap.xml"},
+		Workers:   2,
+		TimeoutMS: 3000,
+	})
+
+	events := waitJob(t, c, baseUrl, id)
+	require.NotEmpty(t, events)
+
+	want := []string{
+		srv.URL + "/page/inline",
+		srv.URL + "/page/1",
+		srv.URL + "/page/2",
+		srv.URL + "/page/3",
+		srv.URL + "/page/4",
+	}
+
+	got := make([]CrawlResponse, len(want))
+	results := 0
+
+	for _, e := range events {
+		if e.Name != "result" {
+			continue
+		}
+
+		var r sseResult
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &r))
+		require.Less(t, r.Index, len(want))
+
+		got[r.Index] = r.CrawlResponse
+		results++
+	}
+
+	require.Equal(t, len(want), results)
+	for i := range want {
+		require.Equal(t, want[i], got[i].URL)
+		require.Equal(t, http.StatusOK, got[i].StatusCode)
+	}
+
+	last := events[len(events)-1]
+	require.Equal(t, "done", last.Name)
+
+	var done struct {
+		Sources []sourceSummary `json:"sources"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(last.Data), &done))
+	require.Len(t, done.Sources, 4)
+
+	require.Equal(t, sourceSummary{Kind: "inline", URLs: 2}, done.Sources[0])
+	require.Equal(t, sourceSummary{Kind: "url_list", Source: srv.URL + "/list.txt", URLs: 3, Duplicates: 1}, done.Sources[1])
+
+	require.Equal(t, "url_list", done.Sources[2].Kind)
+	require.Equal(t, srv.URL+"/missing.txt", done.Sources[2].Source)
+	require.Zero(t, done.Sources[2].URLs)
+	require.NotEmpty(t, done.Sources[2].Error)
+
+	require.Equal(t, sourceSummary{Kind: "sitemap", Source: srv.URL + "/sitemap.xml", URLs: 2, Duplicates: 1}, done.Sources[3])
+}
+
+func TestMultiSourceCrawl(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newSourcesServer(t)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLLists:  []string{srv.URL + "/list.txt"},
+		Sitemaps:  []string{srv.URL + "/sitemap.xml"},
+		Workers:   2,
+		TimeoutMS: 3000,
+	})
+
+	require.Len(t, got, 4)
+
+	for i, r := range got {
+		require.True(t, strings.HasSuffix(r.URL, fmt.Sprintf("/page/%d", i+1)), r.URL)
+		require.Equal(t, http.StatusOK, r.StatusCode)
+	}
+}
+
+func TestInlineDuplicatesKeptWithoutSources(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newSourcesServer(t)
+
+	urls := []string{srv.URL + "/page/1", srv.URL + "/page/1"}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, len(urls))
+}
@@ -0,0 +1,131 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const spillPattern = "crawler-spill-*"
+
+func TestCrawlSpillsResultsToDisk(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	gate := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-gate:
+		case <-r.Context().Done():
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	// невалидные урлы обрабатываются мгновенно, а их ошибки занимают место
+	n := 2 * resultsSpillThreshold / 100
+	urls := make([]string, 0, n+1)
+
+	for i := range n {
+		urls = append(urls, fmt.Sprintf("http://example.com:abc/item-%d", i))
+	}
+
+	urls = append(urls, srv.URL)
+
+	// spills вызывается из условий Eventually, поэтому вместо require возвращает -1 при ошибке
+	spills := func() int {
+		matches, err := filepath.Glob(filepath.Join(tmp, spillPattern))
+		if err != nil {
+			return -1
+		}
+
+		return len(matches)
+	}
+
+	done := crawlAsync(c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   8,
+		TimeoutMS: 30_000,
+	})
+
+	require.Eventually(t, func() bool {
+		return spills() > 0
+	}, 10*time.Second, 20*time.Millisecond, "expected results to be spilled to a temp file")
+
+	close(gate)
+
+	got := awaitCrawl(t, done)
+
+	require.Len(t, got, len(urls))
+	for i := range n {
+		require.Equal(t, urls[i], got[i].URL)
+		require.Equal(t, errorCodeInvalidURL, got[i].ErrorCode)
+	}
+
+	require.Equal(t, srv.URL, got[n].URL)
+	require.Equal(t, http.StatusNoContent, got[n].StatusCode)
+
+	require.Eventually(t, func() bool {
+		return spills() == 0
+	}, time.Second, 20*time.Millisecond, "spill file must be removed after response")
+}
+
+func TestCrawlSpillRemovedOnCancel(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+
+	t.Cleanup(srv.Close)
+
+	n := 2 * resultsSpillThreshold / 100
+	urls := make([]string, 0, n+1)
+
+	for i := range n {
+		urls = append(urls, fmt.Sprintf("http://example.com:abc/item-%d", i))
+	}
+
+	urls = append(urls, srv.URL)
+
+	// клиент сдаётся раньше, чем закончится обход
+	c := client()
+	c.Timeout = 2 * time.Second
+
+	reqBody, err := json.Marshal(CrawlRequest{
+		URLs:      urls,
+		Workers:   8,
+		TimeoutMS: 30_000,
+	})
+	require.NoError(t, err)
+
+	_, err = c.Post(constructCrawlPath(t, baseUrl).String(), contentTypeJson, bytes.NewReader(reqBody))
+	require.Error(t, err)
+
+	require.Eventually(t, func() bool {
+		entries, err := os.ReadDir(tmp)
+		return err == nil && len(entries) == 0
+	}, 5*time.Second, 20*time.Millisecond, "spill file must be removed when request is canceled")
+}
@@ -0,0 +1,228 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const contentTypeEventStream = "text/event-stream"
+
+type sseEvent struct {
+	ID   string
+	Name string
+	Data string
+}
+
+type sseResult struct {
+	CrawlResponse
+	Index int `json:"index"`
+}
+
+type sseProgress struct {
+	Done   int `json:"done"`
+	Failed int `json:"failed"`
+	Total  int `json:"total"`
+}
+
+func readSSE(t *testing.T, r io.Reader) []sseEvent {
+	t.Helper()
+
+	var (
+		events  []sseEvent
+		current sseEvent
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "":
+			if current.Name != "" || current.Data != "" {
+				events = append(events, current)
+			}
+
+			current = sseEvent{}
+		case strings.HasPrefix(line, "event:"):
+			current.Name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "id:"):
+			current.ID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "data:"):
+			current.Data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+func waitJob(t *testing.T, c *http.Client, baseURL *url.URL, id string) []sseEvent {
+	t.Helper()
+
+	resp, err := c.Get(constructJobsPath(t, baseURL, id, "events").String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	return readSSE(t, resp.Body)
+}
+
+func TestJobEventsStream(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(func() {
+		srv.Close()
+	})
+
+	const n = 10
+	urls := makeURLs(t, srv.URL, n)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 3000,
+	})
+
+	resp, err := c.Get(constructJobsPath(t, baseUrl, id, "events").String())
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Content-Type"), contentTypeEventStream)
+
+	events := readSSE(t, resp.Body)
+	require.NotEmpty(t, events)
+	require.Equal(t, "done", events[len(events)-1].Name)
+
+	var (
+		results  = make([]CrawlResponse, n)
+		progress []sseProgress
+	)
+
+	for _, e := range events {
+		switch e.Name {
+		case "result":
+			var r sseResult
+			require.NoError(t, json.Unmarshal([]byte(e.Data), &r))
+			require.GreaterOrEqual(t, r.Index, 0)
+			require.Less(t, r.Index, n)
+
+			results[r.Index] = r.CrawlResponse
+		case "progress":
+			var p sseProgress
+			require.NoError(t, json.Unmarshal([]byte(e.Data), &p))
+			progress = append(progress, p)
+		}
+	}
+
+	for i := range urls {
+		require.Equal(t, urls[i], results[i].URL)
+		require.Empty(t, results[i].Error)
+		require.Equal(t, http.StatusNoContent, results[i].StatusCode)
+	}
+
+	require.Len(t, progress, n)
+	for i, p := range progress {
+		require.Equal(t, i+1, p.Done)
+		require.Equal(t, n, p.Total)
+	}
+}
+
+func TestJobEventsLateSubscriber(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(func() {
+		srv.Close()
+	})
+
+	const n = 3
+	urls := makeURLs(t, srv.URL, n)
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   n,
+		TimeoutMS: 1000,
+	})
+
+	// hint: результаты задачи нужно хранить, а не только рассылать текущим подписчикам
+	resultsURL := constructJobsPath(t, baseUrl, id, "results").String()
+
+	require.Eventually(t, func() bool {
+		resp, err := c.Get(resultsURL)
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+
+		var page jobResultsPage
+		if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&page) != nil {
+			return false
+		}
+
+		return page.Finished
+	}, 5*time.Second, 20*time.Millisecond, "job must finish before the late subscribe")
+
+	resp, err := c.Get(constructJobsPath(t, baseUrl, id, "events").String())
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	results := 0
+	for _, e := range readSSE(t, resp.Body) {
+		if e.Name == "result" {
+			results++
+		}
+	}
+
+	require.Equal(t, n, results)
+}
+
+func TestJobEventsUnknownJob(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	resp, err := c.Get(constructJobsPath(t, baseUrl, "unknown", "events").String())
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
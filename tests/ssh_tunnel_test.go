@@ -0,0 +1,273 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const errorCodeSSHTunnelFailed = "ssh_tunnel_failed"
+
+// bastion - минимальный SSH-сервер, который умеет только direct-tcpip и разрешает имена по своей таблице
+type bastion struct {
+	addr    string
+	hostKey ssh.PublicKey
+	conns   atomic.Int64
+
+	mu   sync.Mutex
+	live []*ssh.ServerConn
+}
+
+func newSigner(t *testing.T) (ssh.Signer, ed25519.PrivateKey) {
+	t.Helper()
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+
+	return signer, priv
+}
+
+func newBastion(t *testing.T, clientKey ssh.PublicKey, resolve map[string]string) *bastion {
+	t.Helper()
+
+	hostSigner, _ := newSigner(t)
+
+	cfg := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, nil
+			}
+
+			return nil, io.ErrUnexpectedEOF
+		},
+	}
+
+	cfg.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	b := &bastion{addr: ln.Addr().String(), hostKey: hostSigner.PublicKey()}
+
+	t.Cleanup(func() {
+		ln.Close()
+		b.dropAll()
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go b.serve(conn, cfg, resolve)
+		}
+	}()
+
+	return b
+}
+
+func (b *bastion) serve(conn net.Conn, cfg *ssh.ServerConfig, resolve map[string]string) {
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	b.conns.Add(1)
+
+	b.mu.Lock()
+	b.live = append(b.live, sconn)
+	b.mu.Unlock()
+
+	go ssh.DiscardRequests(reqs)
+
+	for newCh := range chans {
+		if newCh.ChannelType() != "direct-tcpip" {
+			_ = newCh.Reject(ssh.UnknownChannelType, "only direct-tcpip")
+			continue
+		}
+
+		var target struct {
+			Host     string
+			Port     uint32
+			OrigHost string
+			OrigPort uint32
+		}
+
+		if err := ssh.Unmarshal(newCh.ExtraData(), &target); err != nil {
+			_ = newCh.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		host := target.Host
+		if ip, ok := resolve[host]; ok {
+			host = ip
+		}
+
+		upstream, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(target.Port))))
+		if err != nil {
+			_ = newCh.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		ch, chReqs, err := newCh.Accept()
+		if err != nil {
+			upstream.Close()
+			continue
+		}
+
+		go ssh.DiscardRequests(chReqs)
+
+		go func() {
+			defer ch.Close()
+			defer upstream.Close()
+
+			go func() {
+				_, _ = io.Copy(upstream, ch)
+				_ = upstream.(*net.TCPConn).CloseWrite()
+			}()
+
+			_, _ = io.Copy(ch, upstream)
+		}()
+	}
+}
+
+// dropAll рвёт все SSH-соединения, как при перезапуске бастиона
+func (b *bastion) dropAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, c := range b.live {
+		c.Close()
+	}
+
+	b.live = nil
+}
+
+// setSSHJumps пишет ключ клиента и known_hosts во временный каталог и настраивает jump-хост.
+// Вызывать до старта краулера.
+func setSSHJumps(t *testing.T, pattern string, b *bastion, clientKey ed25519.PrivateKey, trusted ssh.PublicKey) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	block, err := ssh.MarshalPrivateKey(clientKey, "")
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(dir, "id_ed25519")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600))
+
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(b.addr)}, trusted)
+	require.NoError(t, os.WriteFile(knownHostsFile, []byte(line+"\n"), 0o600))
+
+	prev := sshJumps
+	sshJumps = []sshJump{{
+		hostPattern:    pattern,
+		address:        b.addr,
+		user:           "crawler",
+		keyFile:        keyFile,
+		knownHostsFile: knownHostsFile,
+		keepAlive:      200 * time.Millisecond,
+	}}
+
+	t.Cleanup(func() {
+		sshJumps = prev
+	})
+}
+
+func TestSSHTunnel(t *testing.T) {
+	clientSigner, clientKey := newSigner(t)
+	b := newBastion(t, clientSigner.PublicKey(), map[string]string{"app.internal.test": "127.0.0.1"})
+
+	setSSHJumps(t, "*.internal.test", b, clientKey, b.hostKey)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	// имя app.internal.test известно только бастиону
+	internal := "http://app.internal.test:" + u.Port()
+	urls := []string{internal + "/200", internal + "/201", internal + "/202", internal + "/203", srv.URL + "/204"}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   len(urls),
+		TimeoutMS: 5000,
+	})
+
+	require.Len(t, got, len(urls))
+	for i, code := range []int{200, 201, 202, 203, 204} {
+		require.Empty(t, got[i].Error, urls[i])
+		require.Equal(t, code, got[i].StatusCode, urls[i])
+	}
+
+	require.EqualValues(t, 1, b.conns.Load())
+
+	b.dropAll()
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{internal + "/206"},
+		Workers:   1,
+		TimeoutMS: 5000,
+	})
+
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].Error)
+	require.Equal(t, 206, got[0].StatusCode)
+	require.EqualValues(t, 2, b.conns.Load())
+}
+
+func TestSSHTunnelUnknownHostKey(t *testing.T) {
+	clientSigner, clientKey := newSigner(t)
+	b := newBastion(t, clientSigner.PublicKey(), map[string]string{"app.internal.test": "127.0.0.1"})
+
+	impostor, _ := newSigner(t)
+	setSSHJumps(t, "*.internal.test", b, clientKey, impostor.PublicKey())
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://app.internal.test:" + u.Port() + "/200"},
+		Workers:   1,
+		TimeoutMS: 5000,
+	})
+
+	require.Len(t, got, 1)
+	require.Zero(t, got[0].StatusCode)
+	require.Equal(t, errorCodeSSHTunnelFailed, got[0].ErrorCode)
+}
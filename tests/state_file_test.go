@@ -0,0 +1,335 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const stateFileEnv = "CRAWLER_STATE_FILE"
+
+func TestStateFileRestart(t *testing.T) {
+	t.Setenv(stateFileEnv, filepath.Join(t.TempDir(), "state.json"))
+
+	clk := newFakeClock()
+	c := client()
+
+	var hits atomic.Int64
+	cached := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	t.Cleanup(cached.Close)
+
+	gated, inFlight, release := newGateServer(t)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	baseUrl, stopWait := startCrawlerServerWithClock(ctx, t, clk)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{cached.URL + "/page"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.EqualValues(t, 1, hits.Load())
+
+	urls := makeURLs(t, gated.URL+"/state", 2)
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 10_000,
+	})
+
+	require.Eventually(t, func() bool {
+		return inFlight("state") == 2
+	}, time.Second, 20*time.Millisecond)
+
+	require.Equal(t, http.StatusCreated, templateRequest(t, c, http.MethodPut, baseUrl.JoinPath(templatesPath, "kept"), CrawlRequest{
+		URLs:      []string{cached.URL + "/page"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	}).StatusCode)
+
+	cancel()
+	stopWait()
+
+	baseUrl, stopWait = startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	// кэш пережил перезапуск
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{cached.URL + "/page"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusAccepted, got[0].StatusCode)
+	require.EqualValues(t, 1, hits.Load())
+
+	require.Equal(t, http.StatusOK, templateRequest(t, c, http.MethodGet, baseUrl.JoinPath(templatesPath, "kept"), nil).StatusCode)
+
+	// задача продолжилась с тем же id
+	require.Eventually(t, func() bool {
+		return inFlight("state") == 2
+	}, 2*time.Second, 20*time.Millisecond)
+
+	release()
+
+	results := 0
+	for _, e := range waitJob(t, c, baseUrl, id) {
+		if e.Name != "result" {
+			continue
+		}
+
+		var r sseResult
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &r))
+		require.Equal(t, urls[r.Index], r.URL)
+		require.Equal(t, http.StatusNoContent, r.StatusCode)
+
+		results++
+	}
+
+	require.Equal(t, len(urls), results)
+}
+
+func TestStateFileCorrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+
+	t.Setenv(stateFileEnv, path)
+
+	port := findFreePort(t)
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- New().ListenAndServe(t.Context(), port)
+	}()
+
+	select {
+	case err := <-errCh:
+		require.Error(t, err)
+	case <-time.After(serverDownTTL):
+		t.Fatal("ListenAndServe did not fail on corrupted state file")
+	}
+}
+
+const (
+	pinsPath      = "/admin/pins"
+	schedulesPath = "/admin/schedules"
+)
+
+type pinList struct {
+	URLs []string `json:"urls"`
+}
+
+type schedule struct {
+	Name       string    `json:"name"`
+	Template   string    `json:"template"`
+	IntervalMS int       `json:"interval_ms"`
+	NextRun    time.Time `json:"next_run"`
+}
+
+func getSchedules(t *testing.T, c *http.Client, baseURL *url.URL) []schedule {
+	t.Helper()
+
+	resp := templateRequest(t, c, http.MethodGet, baseURL.JoinPath(schedulesPath), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got struct {
+		Schedules []schedule `json:"schedules"`
+	}
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	return got.Schedules
+}
+
+func TestStateFilePinsAndSchedules(t *testing.T) {
+	t.Setenv(stateFileEnv, filepath.Join(t.TempDir(), "state.json"))
+
+	clk := newFakeClock()
+	c := client()
+
+	var pinnedHits, scheduledHits atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pinned" {
+			pinnedHits.Add(1)
+		} else {
+			scheduledHits.Add(1)
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	const interval = 10 * time.Second
+
+	pinned := srv.URL + "/pinned"
+
+	ctx, cancel := context.WithCancel(t.Context())
+	baseUrl, stopWait := startCrawlerServerWithClock(ctx, t, clk)
+
+	require.Equal(t, http.StatusCreated, templateRequest(t, c, http.MethodPut, baseUrl.JoinPath(templatesPath, "kept"), CrawlRequest{
+		URLs:      []string{srv.URL + "/scheduled"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	}).StatusCode)
+
+	require.Equal(t, http.StatusNoContent, templateRequest(t, c, http.MethodPut, baseUrl.JoinPath(schedulesPath, "nightly"), map[string]any{
+		"template":    "kept",
+		"interval_ms": interval.Milliseconds(),
+	}).StatusCode)
+
+	require.Equal(t, http.StatusNoContent, templateRequest(t, c, http.MethodPut, baseUrl.JoinPath(pinsPath), pinList{
+		URLs: []string{pinned},
+	}).StatusCode)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{pinned},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.EqualValues(t, 1, pinnedHits.Load())
+
+	before := getSchedules(t, c, baseUrl)
+	require.Len(t, before, 1)
+
+	cancel()
+	stopWait()
+
+	// запись закреплённого урла истекла бы, будь он обычным
+	clk.Advance(cacheTTL + time.Second)
+
+	baseUrl, stopWait = startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	normalized, err := normalizeURL(pinned)
+	require.NoError(t, err)
+
+	resp := templateRequest(t, c, http.MethodGet, baseUrl.JoinPath(pinsPath), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var pins pinList
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&pins))
+	require.Equal(t, []string{normalized}, pins.URLs)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{pinned},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.EqualValues(t, 1, pinnedHits.Load(), "pinned entry must survive restart and cacheTTL")
+
+	after := getSchedules(t, c, baseUrl)
+	require.Len(t, after, 1)
+	require.Equal(t, "nightly", after[0].Name)
+	require.Equal(t, "kept", after[0].Template)
+	require.EqualValues(t, interval.Milliseconds(), after[0].IntervalMS)
+	require.True(t, before[0].NextRun.Equal(after[0].NextRun), "next_run must survive restart")
+
+	require.Zero(t, scheduledHits.Load())
+
+	clk.Advance(interval)
+
+	require.Eventually(t, func() bool {
+		return scheduledHits.Load() == 1
+	}, 2*time.Second, 20*time.Millisecond, "restored schedule must run at its next_run")
+}
+
+func TestStateFileRestoredJobKeepsResults(t *testing.T) {
+	t.Setenv(stateFileEnv, filepath.Join(t.TempDir(), "state.json"))
+
+	c := client()
+
+	var fastHits atomic.Int64
+
+	gate := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fast" {
+			fastHits.Add(1)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		select {
+		case <-gate:
+		case <-r.Context().Done():
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	baseUrl, stopWait := startCrawlerServer(ctx, t)
+
+	urls := []string{srv.URL + "/fast", srv.URL + "/slow"}
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 10_000,
+	})
+
+	// дожидаемся, пока результат быстрого урла попадёт в задачу
+	resp, err := c.Get(constructJobsPath(t, baseUrl, id, "events").String())
+	require.NoError(t, err)
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if scanner.Text() == "event: result" {
+			break
+		}
+	}
+
+	require.NoError(t, scanner.Err())
+	resp.Body.Close()
+
+	cancel()
+	stopWait()
+
+	baseUrl, stopWait = startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	close(gate)
+
+	results := make(map[int]int)
+	for _, e := range waitJob(t, c, baseUrl, id) {
+		if e.Name != "result" {
+			continue
+		}
+
+		var r sseResult
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &r))
+		require.Equal(t, urls[r.Index], r.URL)
+
+		results[r.Index] = r.StatusCode
+	}
+
+	require.Equal(t, map[int]int{0: http.StatusOK, 1: http.StatusNoContent}, results)
+	require.EqualValues(t, 1, fastHits.Load(), "result ready before restart must not be fetched again")
+}
@@ -0,0 +1,116 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const statsdAddrEnv = "CRAWLER_STATSD_ADDR"
+
+func listenStatsd(t *testing.T) (addr string, metrics func() []string) {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return conn.LocalAddr().String(), func() []string {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+
+		buf := make([]byte, 64<<10)
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+
+		return strings.Split(strings.TrimSpace(string(buf[:n])), "\n")
+	}
+}
+
+func TestStatsdJobSummary(t *testing.T) {
+	addr, metrics := listenStatsd(t)
+	t.Setenv(statsdAddrEnv, addr)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	urls := []string{
+		srv.URL + "/200",
+		srv.URL + "/404",
+		srv.URL + "/204",
+		srv.URL + "/503",
+	}
+
+	crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	got := metrics()
+	require.Len(t, got, 4)
+	require.Regexp(t, `^crawler\.job\.duration_ms:\d+\|ms\|#api:crawl$`, got[0])
+	require.Equal(t, "crawler.job.urls:4|c|#api:crawl", got[1])
+	require.Equal(t, "crawler.job.failures:2|c|#api:crawl", got[2])
+	require.Equal(t, "crawler.job.cache_hit_ratio:0|g|#api:crawl", got[3])
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls[:2],
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	waitJob(t, c, baseUrl, id)
+
+	got = metrics()
+	require.Len(t, got, 4)
+	require.Regexp(t, `^crawler\.job\.duration_ms:\d+\|ms\|#api:jobs$`, got[0])
+	require.Equal(t, "crawler.job.urls:2|c|#api:jobs", got[1])
+	require.Equal(t, "crawler.job.failures:1|c|#api:jobs", got[2])
+	require.Equal(t, "crawler.job.cache_hit_ratio:1|g|#api:jobs", got[3])
+}
+
+func TestStatsdUnavailable(t *testing.T) {
+	// порт, на котором никто не слушает
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := conn.LocalAddr().String()
+	require.NoError(t, conn.Close())
+
+	t.Setenv(statsdAddrEnv, addr)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	for range 3 {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{srv.URL},
+			Workers:   1,
+			TimeoutMS: 1000,
+		})
+
+		require.Len(t, got, 1)
+		require.Equal(t, http.StatusOK, got[0].StatusCode)
+	}
+}
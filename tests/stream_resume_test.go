@@ -0,0 +1,204 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	lastEventIDHeader    = "Last-Event-ID"
+	lastEventIndexHeader = "Last-Event-Index"
+	contentTypeNDJSON    = "application/x-ndjson"
+)
+
+func resumeEvents(t *testing.T, c *http.Client, baseURL *url.URL, id string, header, last, accept string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, constructJobsPath(t, baseURL, id, "events").String(), nil)
+	require.NoError(t, err)
+
+	req.Header.Set(header, last)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	return resp
+}
+
+func TestJobEventsResume(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	gate := make(chan struct{})
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			close(gate)
+		})
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-gate:
+			case <-r.Context().Done():
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(func() {
+		release()
+		srv.Close()
+	})
+
+	urls := append(makeURLs(t, srv.URL, 3), srv.URL+"/slow")
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   len(urls),
+		TimeoutMS: 5000,
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st, _ := pollAsync(t, c, baseUrl, id)
+		if st.Progress.Done == 3 {
+			break
+		}
+
+		require.True(t, time.Now().Before(deadline), "fast urls did not finish")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	resp := resumeEvents(t, c, baseUrl, id, lastEventIDHeader, "3", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), contentTypeEventStream))
+
+	release()
+	events := readSSE(t, resp.Body)
+
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Name)
+	}
+
+	require.Equal(t, []string{"result", "progress", "done"}, names)
+	require.Equal(t, "4", events[0].ID)
+	require.Empty(t, events[1].ID)
+
+	var r sseResult
+	require.NoError(t, json.Unmarshal([]byte(events[0].Data), &r))
+	require.Equal(t, 3, r.Index)
+	require.Equal(t, srv.URL+"/slow", r.URL)
+
+	var p sseProgress
+	require.NoError(t, json.Unmarshal([]byte(events[1].Data), &p))
+	require.Equal(t, sseProgress{Done: 4, Total: 4}, p)
+}
+
+func TestJobEventsIDs(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	const n = 5
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL, n),
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	seq := 0
+	for _, e := range waitJob(t, c, baseUrl, id) {
+		if e.Name != "result" {
+			require.Empty(t, e.ID, e.Name)
+			continue
+		}
+
+		seq++
+		require.Equal(t, strconv.Itoa(seq), e.ID)
+	}
+
+	require.Equal(t, n, seq)
+
+	// переподключение после конца задачи отдаёт только недостающий хвост
+	resp := resumeEvents(t, c, baseUrl, id, lastEventIDHeader, "2", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var ids []string
+	for _, e := range readSSE(t, resp.Body) {
+		if e.Name == "result" {
+			ids = append(ids, e.ID)
+		}
+	}
+
+	require.Equal(t, []string{"3", "4", "5"}, ids)
+}
+
+func TestJobEventsNDJSONResume(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	urls := makeURLs(t, srv.URL, 4)
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+	})
+
+	waitJob(t, c, baseUrl, id)
+
+	resp := resumeEvents(t, c, baseUrl, id, lastEventIndexHeader, "1", contentTypeNDJSON)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), contentTypeNDJSON))
+
+	var seqs []int
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var r jobResult
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		require.Equal(t, urls[r.Index], r.URL)
+		require.Equal(t, http.StatusOK, r.StatusCode)
+
+		seqs = append(seqs, r.Seq)
+	}
+
+	require.NoError(t, scanner.Err())
+	require.Equal(t, []int{2, 3, 4}, seqs)
+
+	for _, tc := range []struct{ header, value, accept string }{
+		{lastEventIDHeader, "abc", ""},
+		{lastEventIDHeader, "-1", ""},
+		{lastEventIndexHeader, "1.5", contentTypeNDJSON},
+	} {
+		resp := resumeEvents(t, c, baseUrl, id, tc.header, tc.value, tc.accept)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, tc)
+	}
+}
@@ -0,0 +1,162 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newStatusServer отвечает кодом из последнего сегмента пути: /item/404 -> 404
+func newStatusServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, err := strconv.Atoi(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		if err != nil {
+			code = http.StatusOK
+		}
+
+		w.WriteHeader(code)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCrawlSuccessStatusesDefault(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	urls := []string{
+		srv.URL + "/204",
+		srv.URL + "/304",
+		srv.URL + "/404",
+		srv.URL + "/500",
+		closed.URL,
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   len(urls),
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, len(urls))
+
+	expected := []bool{true, true, false, false, false}
+	for i := range urls {
+		require.Equal(t, expected[i], got[i].Success, "url %s", urls[i])
+	}
+}
+
+func TestCrawlSuccessStatusesCustom(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	urls := []string{
+		srv.URL + "/200",
+		srv.URL + "/401",
+		srv.URL + "/403",
+		srv.URL + "/404",
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:            urls,
+		Workers:         len(urls),
+		TimeoutMS:       2000,
+		SuccessStatuses: []string{"200-299", "401", "403"},
+	})
+
+	require.Len(t, got, len(urls))
+
+	expected := []bool{true, true, true, false}
+	for i := range urls {
+		require.Equal(t, expected[i], got[i].Success, "url %s", urls[i])
+	}
+
+	// hint: в кэше хранится код ответа, а успех вычисляется для каждого запроса заново
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:            urls,
+		Workers:         len(urls),
+		TimeoutMS:       2000,
+		SuccessStatuses: []string{"404"},
+	})
+
+	require.Len(t, got, len(urls))
+
+	expected = []bool{false, false, false, true}
+	for i := range urls {
+		require.Equal(t, expected[i], got[i].Success, "url %s", urls[i])
+	}
+}
+
+func TestCrawlSuccessStatusesInvalid(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, statuses := range [][]string{
+		{"abc"},
+		{"300-200"},
+		{"99"},
+		{"200-600"},
+		{"200-"},
+	} {
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{
+			URLs:            []string{"http://example.com"},
+			Workers:         1,
+			TimeoutMS:       1000,
+			SuccessStatuses: statuses,
+		})
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "statuses %v", statuses)
+	}
+}
+
+func TestJobProgressFailed(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	urls := []string{
+		srv.URL + "/200",
+		srv.URL + "/404",
+		srv.URL + "/503",
+	}
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	var last sseProgress
+	for _, e := range waitJob(t, c, baseUrl, id) {
+		if e.Name == "progress" {
+			require.NoError(t, json.Unmarshal([]byte(e.Data), &last))
+		}
+	}
+
+	require.Equal(t, len(urls), last.Done)
+	require.Equal(t, 2, last.Failed)
+	require.Equal(t, len(urls), last.Total)
+}
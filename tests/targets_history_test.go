@@ -0,0 +1,212 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	jobHistoryEnv = "CRAWLER_JOB_HISTORY"
+	targetsPath   = "/targets"
+)
+
+type target struct {
+	URL                string    `json:"url"`
+	StatusCode         int       `json:"status_code"`
+	Hash               string    `json:"hash"`
+	JobID              string    `json:"job_id"`
+	SeenAt             time.Time `json:"seen_at"`
+	PreviousStatusCode int       `json:"previous_status_code"`
+	PreviousHash       string    `json:"previous_hash"`
+	ChangedAt          time.Time `json:"changed_at"`
+	Changes            int       `json:"changes"`
+	Instance           string    `json:"instance"`
+}
+
+func getTargets(t *testing.T, c *http.Client, baseURL *url.URL, query url.Values) *http.Response {
+	t.Helper()
+
+	u := baseURL.JoinPath(targetsPath)
+	u.RawQuery = query.Encode()
+
+	resp, err := c.Get(u.String())
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	return resp
+}
+
+func TestTargetsHistory(t *testing.T) {
+	t.Setenv(jobHistoryEnv, "1")
+
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var flaps atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flap" && flaps.Add(1)%2 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL + "/stable", srv.URL + "/flap"},
+		Workers:   2,
+		TimeoutMS: 2000,
+	}
+
+	runJob := func() string {
+		id := submitJob(t, c, baseUrl, req)
+		waitJob(t, c, baseUrl, id)
+
+		// следующая задача не должна попасть в кэш ответов
+		clk.Advance(cacheTTL + time.Second)
+		return id
+	}
+
+	start := clk.Now()
+	runJob()
+
+	beforeFlap := clk.Now()
+	second := runJob()
+
+	resp := getTargets(t, c, baseUrl, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var all []target
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&all))
+	require.Len(t, all, 2)
+
+	require.Equal(t, srv.URL+"/flap", all[0].URL)
+	require.Equal(t, http.StatusServiceUnavailable, all[0].StatusCode)
+	require.Equal(t, second, all[0].JobID)
+	require.Equal(t, http.StatusOK, all[0].PreviousStatusCode)
+	require.Equal(t, sha256Hex(""), all[0].Hash)
+	require.Equal(t, sha256Hex(""), all[0].PreviousHash)
+	require.Equal(t, 1, all[0].Changes)
+	require.True(t, all[0].ChangedAt.After(beforeFlap) || all[0].ChangedAt.Equal(beforeFlap))
+
+	require.Equal(t, srv.URL+"/stable", all[1].URL)
+	require.Equal(t, http.StatusOK, all[1].StatusCode)
+	require.Equal(t, second, all[1].JobID)
+	require.Zero(t, all[1].PreviousStatusCode)
+	require.Equal(t, sha256Hex(""), all[1].Hash)
+	require.Empty(t, all[1].PreviousHash)
+	require.True(t, all[1].ChangedAt.IsZero())
+	require.Zero(t, all[1].Changes)
+	require.False(t, all[1].SeenAt.Before(start))
+
+	resp = getTargets(t, c, baseUrl, url.Values{"changed_since": {start.Format(time.RFC3339Nano)}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var changed []target
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&changed))
+	require.Len(t, changed, 1)
+	require.Equal(t, srv.URL+"/flap", changed[0].URL)
+
+	resp = getTargets(t, c, baseUrl, url.Values{"changed_since": {clk.Now().Format(time.RFC3339Nano)}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&changed))
+	require.Empty(t, changed)
+
+	resp = getTargets(t, c, baseUrl, url.Values{"changed_since": {"yesterday"}})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestTargetsHistoryBodyHash(t *testing.T) {
+	t.Setenv(jobHistoryEnv, "1")
+
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var versions atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/content" {
+			fmt.Fprintf(w, "v%d", versions.Add(1))
+			return
+		}
+
+		io.WriteString(w, "same")
+	}))
+
+	t.Cleanup(srv.Close)
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL + "/content", srv.URL + "/same"},
+		Workers:   2,
+		TimeoutMS: 2000,
+	}
+
+	for range 2 {
+		waitJob(t, c, baseUrl, submitJob(t, c, baseUrl, req))
+		clk.Advance(cacheTTL + time.Second)
+	}
+
+	resp := getTargets(t, c, baseUrl, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var all []target
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&all))
+	require.Len(t, all, 2)
+
+	// код ответа тот же, но тело изменилось - это изменение цели
+	require.Equal(t, srv.URL+"/content", all[0].URL)
+	require.Equal(t, http.StatusOK, all[0].StatusCode)
+	require.Equal(t, http.StatusOK, all[0].PreviousStatusCode)
+	require.Equal(t, sha256Hex("v2"), all[0].Hash)
+	require.Equal(t, sha256Hex("v1"), all[0].PreviousHash)
+	require.Equal(t, 1, all[0].Changes)
+	require.False(t, all[0].ChangedAt.IsZero())
+
+	require.Equal(t, srv.URL+"/same", all[1].URL)
+	require.Equal(t, sha256Hex("same"), all[1].Hash)
+	require.Empty(t, all[1].PreviousHash)
+	require.Zero(t, all[1].Changes)
+}
+
+func TestTargetsHistoryDisabled(t *testing.T) {
+	t.Setenv(jobHistoryEnv, "")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	resp := getTargets(t, c, baseUrl, nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,249 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const templatesPath = "/templates"
+
+func templateRequest(t *testing.T, c *http.Client, method string, target *url.URL, body any) *http.Response {
+	t.Helper()
+
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+
+	req, err := http.NewRequest(method, target.String(), bytes.NewReader(reqBody))
+	require.NoError(t, err)
+
+	if body != nil {
+		req.Header.Set("Content-Type", contentTypeJson)
+	}
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	return resp
+}
+
+func TestTemplatesCRUD(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	nightly := baseUrl.JoinPath(templatesPath, "nightly")
+	req := CrawlRequest{
+		URLs:      []string{srv.URL + "/200", srv.URL + "/404"},
+		Workers:   2,
+		TimeoutMS: 2000,
+	}
+
+	require.Equal(t, http.StatusNotFound, templateRequest(t, c, http.MethodGet, nightly, nil).StatusCode)
+	require.Equal(t, http.StatusCreated, templateRequest(t, c, http.MethodPut, nightly, req).StatusCode)
+
+	resp := templateRequest(t, c, http.MethodGet, nightly, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var stored CrawlRequest
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&stored))
+	require.Equal(t, req.URLs, stored.URLs)
+	require.Equal(t, req.Workers, stored.Workers)
+	require.Equal(t, req.TimeoutMS, stored.TimeoutMS)
+
+	req.URLs = append(req.URLs, srv.URL+"/204")
+	require.Equal(t, http.StatusNoContent, templateRequest(t, c, http.MethodPut, nightly, req).StatusCode)
+	require.Equal(t, http.StatusCreated, templateRequest(t, c, http.MethodPut, baseUrl.JoinPath(templatesPath, "adhoc"), req).StatusCode)
+
+	resp = templateRequest(t, c, http.MethodGet, baseUrl.JoinPath(templatesPath), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var list struct {
+		Templates []string `json:"templates"`
+	}
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Equal(t, []string{"adhoc", "nightly"}, list.Templates)
+
+	require.Equal(t, http.StatusNoContent, templateRequest(t, c, http.MethodDelete, nightly, nil).StatusCode)
+	require.Equal(t, http.StatusNotFound, templateRequest(t, c, http.MethodDelete, nightly, nil).StatusCode)
+	require.Equal(t, http.StatusNotFound, templateRequest(t, c, http.MethodPost, nightly.JoinPath("run"), nil).StatusCode)
+}
+
+func TestTemplatesRun(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv := newStatusServer(t)
+
+	weekly := baseUrl.JoinPath(templatesPath, "weekly")
+	urls := []string{srv.URL + "/200", srv.URL + "/503"}
+
+	require.Equal(t, http.StatusCreated, templateRequest(t, c, http.MethodPut, weekly, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 2000,
+	}).StatusCode)
+
+	resp := templateRequest(t, c, http.MethodPost, weekly.JoinPath("run"), nil)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var job struct {
+		ID string `json:"id"`
+	}
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+	require.NotEmpty(t, job.ID)
+
+	results := make([]CrawlResponse, len(urls))
+	for _, e := range waitJob(t, c, baseUrl, job.ID) {
+		if e.Name != "result" {
+			continue
+		}
+
+		var r sseResult
+		require.NoError(t, json.Unmarshal([]byte(e.Data), &r))
+		results[r.Index] = r.CrawlResponse
+	}
+
+	require.Equal(t, http.StatusOK, results[0].StatusCode)
+	require.Equal(t, http.StatusServiceUnavailable, results[1].StatusCode)
+}
+
+func TestTemplatesSourceRefs(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var pages atomic.Value
+	pages.Store([]string{"/a"})
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			var b strings.Builder
+			b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+
+			for _, p := range pages.Load().([]string) {
+				fmt.Fprintf(&b, "<url><loc>%s%s</loc></url>", srv.URL, p)
+			}
+
+			b.WriteString("</urlset>")
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte(b.String()))
+		case "/robots.txt":
+			// относительный урл и имя директивы в другом регистре
+			_, _ = w.Write([]byte("User-agent: *\nDisallow:\nSITEMAP: /robots-sitemap.xml\n"))
+		case "/robots-sitemap.xml":
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>` +
+				srv.URL + `/from-robots</loc></url></urlset>`))
+		case "/list.txt":
+			_, _ = w.Write([]byte(srv.URL + "/listed\n"))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	sitemaps := baseUrl.JoinPath(templatesPath, "sitemaps")
+	req := CrawlRequest{
+		URLLists:  []string{srv.URL + "/list.txt"},
+		Sitemaps:  []string{srv.URL + "/sitemap.xml", srv.URL + "/robots.txt"},
+		Workers:   2,
+		TimeoutMS: 2000,
+	}
+
+	require.Equal(t, http.StatusCreated, templateRequest(t, c, http.MethodPut, sitemaps, req).StatusCode)
+
+	resp := templateRequest(t, c, http.MethodGet, sitemaps, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var stored CrawlRequest
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&stored))
+	require.Empty(t, stored.URLs, "источники не раскрываются в урлы")
+	require.Equal(t, req.URLLists, stored.URLLists)
+	require.Equal(t, req.Sitemaps, stored.Sitemaps)
+
+	run := func() []string {
+		t.Helper()
+
+		resp := templateRequest(t, c, http.MethodPost, sitemaps.JoinPath("run"), nil)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+		var job struct {
+			ID string `json:"id"`
+		}
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+
+		var urls []string
+		for _, e := range waitJob(t, c, baseUrl, job.ID) {
+			if e.Name != "result" {
+				continue
+			}
+
+			var r sseResult
+			require.NoError(t, json.Unmarshal([]byte(e.Data), &r))
+			require.Equal(t, http.StatusOK, r.StatusCode)
+			urls = append(urls, r.URL)
+		}
+
+		return urls
+	}
+
+	require.ElementsMatch(t, []string{srv.URL + "/listed", srv.URL + "/a", srv.URL + "/from-robots"}, run())
+
+	pages.Store([]string{"/a", "/b"})
+
+	require.ElementsMatch(t, []string{srv.URL + "/listed", srv.URL + "/a", srv.URL + "/b", srv.URL + "/from-robots"}, run(),
+		"каждый запуск читает источники заново")
+}
+
+func TestTemplatesValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	valid := CrawlRequest{
+		URLs:      []string{"http://127.0.0.1/"},
+		Workers:   1,
+		TimeoutMS: 1000,
+	}
+
+	resp := templateRequest(t, c, http.MethodPut, baseUrl.JoinPath(templatesPath, "Bad.Name"), valid)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = templateRequest(t, c, http.MethodPut, baseUrl.JoinPath(templatesPath, "no-workers"), CrawlRequest{
+		URLs:      []string{"http://127.0.0.1/"},
+		TimeoutMS: 1000,
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, http.StatusNotFound, templateRequest(t, c, http.MethodGet, baseUrl.JoinPath(templatesPath, "no-workers"), nil).StatusCode)
+}
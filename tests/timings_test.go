@@ -0,0 +1,127 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func TestCrawlTimings(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	const (
+		headerDelay = 100 * time.Millisecond
+		bodyDelay   = 150 * time.Millisecond
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(headerDelay)
+
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		time.Sleep(bodyDelay)
+		_, _ = w.Write([]byte("body"))
+	}))
+
+	t.Cleanup(srv.Close)
+
+	urls := makeURLs(t, srv.URL, 2)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   1,
+		TimeoutMS: 3000,
+	})
+
+	require.Len(t, got, len(urls))
+
+	for i := range got {
+		tm := got[i].Timings
+		require.NotNil(t, tm)
+
+		require.GreaterOrEqual(t, tm.QueueMS, 0.0)
+		require.GreaterOrEqual(t, tm.ConnectMS, 0.0)
+		require.LessOrEqual(t, tm.ConnectMS, tm.FirstByteMS)
+		require.LessOrEqual(t, tm.FirstByteMS, tm.TotalMS)
+
+		require.GreaterOrEqual(t, tm.FirstByteMS, ms(headerDelay))
+		require.Less(t, tm.FirstByteMS, ms(headerDelay+bodyDelay))
+		require.GreaterOrEqual(t, tm.TotalMS, ms(headerDelay+bodyDelay))
+	}
+
+	// workers: 1 - второй урл ждёт, пока первый обработается целиком
+	require.GreaterOrEqual(t, got[1].Timings.QueueMS, got[0].Timings.QueueMS+got[0].Timings.TotalMS)
+}
+
+func TestCrawlTimingsCached(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL},
+		Workers:   1,
+		TimeoutMS: 3000,
+	}
+
+	got := crawl(t, c, baseUrl, req)
+	require.Len(t, got, 1)
+	require.NotNil(t, got[0].Timings)
+	require.Positive(t, got[0].Timings.TotalMS)
+
+	got = crawl(t, c, baseUrl, req)
+	require.Len(t, got, 1)
+	require.NotNil(t, got[0].Timings)
+	require.Zero(t, got[0].Timings.ConnectMS)
+	require.Zero(t, got[0].Timings.FirstByteMS)
+	require.Zero(t, got[0].Timings.TotalMS)
+}
+
+func TestCrawlTimingsFakeClock(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	const delay = 100 * time.Millisecond
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL},
+		Workers:   1,
+		TimeoutMS: 3000,
+	})
+
+	// замеры идут по монотонным часам, а не по подменяемому clock
+	require.Len(t, got, 1)
+	require.NotNil(t, got[0].Timings)
+	require.GreaterOrEqual(t, got[0].Timings.TotalMS, ms(delay))
+}
@@ -0,0 +1,388 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	errorCodeTLSPinMismatch = "tls_pin_mismatch"
+	errorCodeTLSRevoked     = "tls_revoked"
+)
+
+func spkiPin(srv *httptest.Server) string {
+	sum := sha256.Sum256(srv.Certificate().RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// otherPin - отпечаток свежего ключа, которого нет ни у одного сервера
+func otherPin(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	spki, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(spki)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func TestCrawlTLSPinning(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	trustServer(t, srv)
+
+	other := otherPin(t)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/match"},
+		Workers:   1,
+		TimeoutMS: 2000,
+		TLSPins: map[string][]string{
+			u.Hostname(): {other, spkiPin(srv)},
+		},
+	})
+
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].Error)
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/mismatch"},
+		Workers:   1,
+		TimeoutMS: 2000,
+		TLSPins: map[string][]string{
+			u.Hostname(): {other},
+		},
+	})
+
+	require.Len(t, got, 1)
+	require.NotEmpty(t, got[0].Error)
+	require.Equal(t, errorCodeTLSPinMismatch, got[0].ErrorCode)
+	require.Zero(t, got[0].StatusCode)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/unpinned"},
+		Workers:   1,
+		TimeoutMS: 2000,
+		TLSPins: map[string][]string{
+			"example.com": {other},
+		},
+	})
+
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].Error)
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+}
+
+func TestCrawlTLSPinningInvalidFormat(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, pin := range []string{
+		"",
+		"md5/AAAA",
+		"sha256/not-base64!",
+		"sha256/" + base64.StdEncoding.EncodeToString([]byte("short")),
+	} {
+		resp := postCrawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{"https://example.com"},
+			Workers:   1,
+			TimeoutMS: 1000,
+			TLSPins:   map[string][]string{"example.com": {pin}},
+		})
+
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "pin %q", pin)
+	}
+}
+
+func TestCrawlCheckRevocationSoftFail(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	trustServer(t, srv)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	// у сертификата httptest нет ни OCSP, ни CRL
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:            []string{srv.URL},
+		Workers:         1,
+		TimeoutMS:       2000,
+		CheckRevocation: true,
+	})
+
+	require.Len(t, got, 1)
+	require.Empty(t, got[0].Error)
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+}
+
+// testCA выпускает сертификаты для 127.0.0.1, CRL и OCSP-ответы к ним
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "crawler test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key}
+}
+
+// issue выпускает от ca сертификат для 127.0.0.1; crlURL может быть пустым
+func (ca *testCA) issue(t *testing.T, serial int64, crlURL string) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	if crlURL != "" {
+		tmpl.CRLDistributionPoints = []string{crlURL}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func newServerWithTLSCert(t *testing.T, cert tls.Certificate) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newServerWithCert поднимает TLS-сервер с сертификатом от ca и точкой распространения CRL crlURL
+func (ca *testCA) newServerWithCert(t *testing.T, serial int64, crlURL string) *httptest.Server {
+	t.Helper()
+	return newServerWithTLSCert(t, ca.issue(t, serial, crlURL))
+}
+
+// newServerWithStaple поднимает TLS-сервер с сертификатом от ca без CRL и приложенным OCSP-ответом status
+func (ca *testCA) newServerWithStaple(t *testing.T, serial int64, status int) *httptest.Server {
+	t.Helper()
+
+	cert := ca.issue(t, serial, "")
+
+	tmpl := ocsp.Response{
+		Status:       status,
+		SerialNumber: big.NewInt(serial),
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   time.Now().Add(time.Hour),
+	}
+
+	if status == ocsp.Revoked {
+		tmpl.RevokedAt = time.Now().Add(-time.Minute)
+	}
+
+	staple, err := ocsp.CreateResponse(ca.cert, ca.cert, tmpl, ca.key)
+	require.NoError(t, err)
+
+	cert.OCSPStaple = staple
+	return newServerWithTLSCert(t, cert)
+}
+
+// newCRLServer отдаёт CRL, подписанный ca, с отозванными serials
+func (ca *testCA) newCRLServer(t *testing.T, revoked ...int64) *httptest.Server {
+	t.Helper()
+
+	entries := make([]x509.RevocationListEntry, 0, len(revoked))
+	for _, serial := range revoked {
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: time.Now().Add(-time.Minute),
+		})
+	}
+
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now().Add(-time.Minute),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: entries,
+	}, ca.cert, ca.key)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pkix-crl")
+		_, _ = w.Write(crl)
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCrawlCheckRevocationCRL(t *testing.T) {
+	ca := newTestCA(t)
+	crl := ca.newCRLServer(t, 2)
+
+	revoked := ca.newServerWithCert(t, 2, crl.URL+"/ca.crl")
+	valid := ca.newServerWithCert(t, 3, crl.URL+"/ca.crl")
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	prev := rootCAs
+	rootCAs = pool
+
+	t.Cleanup(func() {
+		rootCAs = prev
+	})
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	req := CrawlRequest{
+		URLs:            []string{revoked.URL + "/", valid.URL + "/"},
+		Workers:         2,
+		TimeoutMS:       3000,
+		CheckRevocation: true,
+	}
+
+	got := crawl(t, c, baseUrl, req)
+
+	require.Len(t, got, 2)
+
+	require.Equal(t, errorCodeTLSRevoked, got[0].ErrorCode)
+	require.NotEmpty(t, got[0].Error)
+	require.Zero(t, got[0].StatusCode)
+
+	require.Empty(t, got[1].Error)
+	require.Equal(t, http.StatusNoContent, got[1].StatusCode)
+
+	// без check_revocation отозванный сертификат не проверяется
+	req.URLs = []string{revoked.URL + "/unchecked"}
+	req.CheckRevocation = false
+
+	got = crawl(t, c, baseUrl, req)
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+}
+
+func TestCrawlCheckRevocationOCSPStaple(t *testing.T) {
+	ca := newTestCA(t)
+
+	revoked := ca.newServerWithStaple(t, 2, ocsp.Revoked)
+	valid := ca.newServerWithStaple(t, 3, ocsp.Good)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	prev := rootCAs
+	rootCAs = pool
+
+	t.Cleanup(func() {
+		rootCAs = prev
+	})
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	req := CrawlRequest{
+		URLs:            []string{revoked.URL + "/", valid.URL + "/"},
+		Workers:         2,
+		TimeoutMS:       3000,
+		CheckRevocation: true,
+	}
+
+	got := crawl(t, c, baseUrl, req)
+
+	require.Len(t, got, 2)
+
+	require.Equal(t, errorCodeTLSRevoked, got[0].ErrorCode)
+	require.NotEmpty(t, got[0].Error)
+	require.Zero(t, got[0].StatusCode)
+
+	require.Empty(t, got[1].Error)
+	require.Equal(t, http.StatusNoContent, got[1].StatusCode)
+
+	// без check_revocation приложенный OCSP-ответ не проверяется
+	req.URLs = []string{revoked.URL + "/unchecked"}
+	req.CheckRevocation = false
+
+	got = crawl(t, c, baseUrl, req)
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+}
@@ -0,0 +1,145 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const errorCodeTLSPolicy = "tls_policy"
+
+// newTLS12Server поддерживает только TLS 1.2 с одним набором шифров
+func newTLS12Server(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	srv.TLS = &tls.Config{
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+	}
+
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestTLSInventory(t *testing.T) {
+	legacy := newTLS12Server(t)
+
+	modern := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(modern.Close)
+
+	plain := newStatusServer(t)
+
+	trustServer(t, legacy, modern)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{legacy.URL, modern.URL, plain.URL + "/200"},
+		Workers:   3,
+		TimeoutMS: 3000,
+	})
+
+	require.Len(t, got, 3)
+
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+	require.NotNil(t, got[0].TLS)
+	require.Equal(t, "1.2", got[0].TLS.Version)
+	require.Equal(t, tls.CipherSuiteName(tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256), got[0].TLS.CipherSuite)
+
+	require.Equal(t, http.StatusNoContent, got[1].StatusCode)
+	require.NotNil(t, got[1].TLS)
+	require.Equal(t, "1.3", got[1].TLS.Version)
+	require.NotEmpty(t, got[1].TLS.CipherSuite)
+
+	require.Equal(t, http.StatusOK, got[2].StatusCode)
+	require.Nil(t, got[2].TLS)
+}
+
+func TestTLSPolicyEnforced(t *testing.T) {
+	legacy := newTLS12Server(t)
+	trustServer(t, legacy)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:          []string{legacy.URL + "/min-version"},
+		Workers:       1,
+		TimeoutMS:     2000,
+		MinTLSVersion: "1.3",
+	})
+
+	require.Len(t, got, 1)
+	require.Zero(t, got[0].StatusCode)
+	require.Equal(t, errorCodeTLSPolicy, got[0].ErrorCode)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:         []string{legacy.URL + "/cipher"},
+		Workers:      1,
+		TimeoutMS:    2000,
+		CipherSuites: []string{tls.CipherSuiteName(tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384)},
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, errorCodeTLSPolicy, got[0].ErrorCode)
+
+	// соединение, установленное без политики, не должно обойти её через пул
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{legacy.URL + "/no-policy"},
+		Workers:   1,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+
+	got = crawl(t, c, baseUrl, CrawlRequest{
+		URLs:          []string{legacy.URL + "/pooled"},
+		Workers:       1,
+		TimeoutMS:     2000,
+		MinTLSVersion: "1.3",
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, errorCodeTLSPolicy, got[0].ErrorCode)
+}
+
+func TestTLSPolicyValidation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	for _, req := range []CrawlRequest{
+		{MinTLSVersion: "1.4"},
+		{MinTLSVersion: "TLS1.2"},
+		{CipherSuites: []string{"TLS_FAKE_CIPHER"}},
+		{CipherSuites: []string{tls.CipherSuiteName(tls.TLS_RSA_WITH_RC4_128_SHA)}},
+	} {
+		req.URLs = []string{"https://127.0.0.1/"}
+		req.Workers = 1
+		req.TimeoutMS = 1000
+
+		resp := postCrawl(t, c, baseUrl, req)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", req)
+	}
+}
@@ -0,0 +1,141 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const tlsSessionsPath = "/debug/tls-sessions"
+
+type tlsSessionStats struct {
+	FullHandshakes    int64 `json:"full_handshakes"`
+	ResumedHandshakes int64 `json:"resumed_handshakes"`
+}
+
+// trustServer добавляет сертификат тестового сервера в корни исходящего транспорта.
+// Вызывать до старта краулера.
+func trustServer(t *testing.T, srvs ...*httptest.Server) {
+	t.Helper()
+
+	pool := x509.NewCertPool()
+	for _, srv := range srvs {
+		pool.AddCert(srv.Certificate())
+	}
+
+	prev := rootCAs
+	rootCAs = pool
+
+	t.Cleanup(func() {
+		rootCAs = prev
+	})
+}
+
+func getTLSSessionStats(t *testing.T, c *http.Client, baseURL *url.URL) tlsSessionStats {
+	t.Helper()
+
+	resp, err := c.Get(baseURL.JoinPath(tlsSessionsPath).String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got tlsSessionStats
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	return got
+}
+
+func TestTLSSessionResumption(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// каждый запрос - новое соединение и новый handshake
+	srv.Config.SetKeepAlivesEnabled(false)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	trustServer(t, srv)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	require.Equal(t, tlsSessionStats{}, getTLSSessionStats(t, c, baseUrl))
+
+	const n = 5
+	urls := makeURLs(t, srv.URL, n)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   1,
+		TimeoutMS: 3000,
+	})
+
+	require.Len(t, got, n)
+	for i := range urls {
+		require.Empty(t, got[i].Error)
+		require.Equal(t, http.StatusNoContent, got[i].StatusCode)
+	}
+
+	require.Equal(t, tlsSessionStats{
+		FullHandshakes:    1,
+		ResumedHandshakes: n - 1,
+	}, getTLSSessionStats(t, c, baseUrl))
+}
+
+func TestTLSSessionKeepAliveNotCounted(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	trustServer(t, srv)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL, 5),
+		Workers:   1,
+		TimeoutMS: 3000,
+	})
+
+	require.Len(t, got, 5)
+	require.Equal(t, tlsSessionStats{FullHandshakes: 1}, getTLSSessionStats(t, c, baseUrl))
+}
+
+func TestTLSUntrustedCertificate(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL},
+		Workers:   1,
+		TimeoutMS: 3000,
+	})
+
+	require.Len(t, got, 1)
+	require.Contains(t, got[0].Error, "certificate")
+	require.Equal(t, errorCodeFetchFailed, got[0].ErrorCode)
+}
@@ -0,0 +1,196 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	traceparentHeader = "traceparent"
+	tracestateHeader  = "tracestate"
+)
+
+var traceparentRe = regexp.MustCompile(`^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+
+type traceHeaders struct {
+	Traceparent string
+	Tracestate  string
+}
+
+func newTraceRecordingServer(t *testing.T) (srv *httptest.Server, recorded func() map[string]traceHeaders) {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]traceHeaders)
+	)
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.Path] = traceHeaders{
+			Traceparent: r.Header.Get(traceparentHeader),
+			Tracestate:  r.Header.Get(tracestateHeader),
+		}
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv, func() map[string]traceHeaders {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return maps.Clone(seen)
+	}
+}
+
+func postCrawlWithHeaders(t *testing.T, c *http.Client, baseURL *url.URL, headers http.Header, body any) *http.Response {
+	t.Helper()
+
+	reqBody, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, constructCrawlPath(t, baseURL).String(), bytes.NewReader(reqBody))
+	require.NoError(t, err)
+
+	req.Header = headers.Clone()
+	req.Header.Set("Content-Type", contentTypeJson)
+
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	return resp
+}
+
+func TestTraceContextPropagation(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, recorded := newTraceRecordingServer(t)
+
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+		state    = "vendor=value"
+	)
+
+	const n = 5
+	urls := makeURLs(t, srv.URL, n)
+
+	resp := postCrawlWithHeaders(t, c, baseUrl, http.Header{
+		traceparentHeader: {"00-" + traceID + "-" + parentID + "-01"},
+		tracestateHeader:  {state},
+	}, CrawlRequest{
+		URLs:      urls,
+		Workers:   n,
+		TimeoutMS: 2000,
+	})
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	m := traceparentRe.FindStringSubmatch(resp.Header.Get(traceparentHeader))
+	require.NotNil(t, m)
+	require.Equal(t, traceID, m[1])
+
+	spans := make(map[string]bool)
+	for path, h := range recorded() {
+		m := traceparentRe.FindStringSubmatch(h.Traceparent)
+		require.NotNil(t, m, "path %s: bad traceparent %q", path, h.Traceparent)
+
+		require.Equal(t, traceID, m[1])
+		require.NotEqual(t, parentID, m[2])
+		require.Equal(t, "01", m[3])
+		require.Equal(t, state, h.Tracestate)
+
+		require.False(t, spans[m[2]], "parent-id must be unique per fetch")
+		spans[m[2]] = true
+	}
+
+	require.Len(t, spans, n)
+}
+
+func TestTraceContextGenerated(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, recorded := newTraceRecordingServer(t)
+
+	for i, headers := range []http.Header{
+		{},
+		{traceparentHeader: {"00-00000000000000000000000000000000-00f067aa0ba902b7-01"}},
+		{traceparentHeader: {"garbage"}},
+	} {
+		urls := makeURLs(t, srv.URL+"/"+strconv.Itoa(i), 3)
+
+		resp := postCrawlWithHeaders(t, c, baseUrl, headers, CrawlRequest{
+			URLs:      urls,
+			Workers:   3,
+			TimeoutMS: 2000,
+		})
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		m := traceparentRe.FindStringSubmatch(resp.Header.Get(traceparentHeader))
+		require.NotNil(t, m)
+		require.NotEqual(t, "00000000000000000000000000000000", m[1])
+
+		seen := recorded()
+		for _, u := range urls {
+			parsed, err := url.Parse(u)
+			require.NoError(t, err)
+
+			h := seen[parsed.Path]
+			got := traceparentRe.FindStringSubmatch(h.Traceparent)
+			require.NotNil(t, got)
+			require.Equal(t, m[1], got[1], "all fetches of one crawl share trace-id")
+			require.Equal(t, "01", got[3])
+			require.Empty(t, h.Tracestate)
+		}
+	}
+}
+
+func TestTraceContextDisabledHosts(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	enabled, enabledRecorded := newTraceRecordingServer(t)
+	disabled, disabledRecorded := newTraceRecordingServer(t)
+
+	disabledURL, err := url.Parse(disabled.URL)
+	require.NoError(t, err)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:               []string{enabled.URL + "/a", disabled.URL + "/b"},
+		Workers:            2,
+		TimeoutMS:          2000,
+		TraceDisabledHosts: []string{disabledURL.Host},
+	})
+
+	require.Len(t, got, 2)
+
+	require.NotEmpty(t, enabledRecorded()["/a"].Traceparent)
+	require.Contains(t, disabledRecorded(), "/b")
+	require.Empty(t, disabledRecorded()["/b"].Traceparent)
+}
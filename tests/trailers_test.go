@@ -0,0 +1,107 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTrailerServer(t *testing.T) (srv *httptest.Server, hits *atomic.Int64) {
+	t.Helper()
+
+	hits = &atomic.Int64{}
+
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		if r.URL.Path == "/plain" {
+			_, _ = w.Write([]byte("no trailers here"))
+			return
+		}
+
+		w.Header().Set("Trailer", "grpc-status, Server-Timing, X-Unsent")
+		w.WriteHeader(http.StatusOK)
+
+		_, _ = w.Write([]byte("streamed body"))
+		w.(http.Flusher).Flush()
+
+		w.Header().Set("Grpc-Status", "14")
+		w.Header().Add("Server-Timing", "db;dur=53")
+		w.Header().Add("Server-Timing", "app;dur=47.2")
+	}))
+
+	t.Cleanup(srv.Close)
+	return srv, hits
+}
+
+func TestCrawlTrailers(t *testing.T) {
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+	srv, hits := newTrailerServer(t)
+
+	req := CrawlRequest{
+		URLs:      []string{srv.URL + "/grpc", srv.URL + "/plain"},
+		Workers:   2,
+		TimeoutMS: 2000,
+	}
+
+	// без флага трейлеры не возвращаются
+	got := crawl(t, c, baseUrl, req)
+	require.Len(t, got, 2)
+	require.Nil(t, got[0].Trailers)
+	require.EqualValues(t, 2, hits.Load())
+
+	// запись кэша без трейлеров не подходит
+	req.Trailers = true
+
+	got = crawl(t, c, baseUrl, req)
+	require.Len(t, got, 2)
+	require.Equal(t, map[string]string{
+		"Grpc-Status":   "14",
+		"Server-Timing": "db;dur=53, app;dur=47.2",
+	}, got[0].Trailers)
+	require.Nil(t, got[1].Trailers)
+	require.EqualValues(t, 4, hits.Load())
+
+	// а запись с трейлерами - подходит
+	got = crawl(t, c, baseUrl, req)
+	require.Len(t, got, 2)
+	require.Equal(t, "14", got[0].Trailers["Grpc-Status"])
+	require.EqualValues(t, 4, hits.Load())
+}
+
+func TestCrawlTrailersTLS(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "Grpc-Status")
+		_, _ = w.Write([]byte("h2 body"))
+		w.Header().Set("Grpc-Status", "0")
+	}))
+
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	trustServer(t, srv)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:      []string{srv.URL + "/h2"},
+		Workers:   1,
+		TimeoutMS: 2000,
+		Trailers:  true,
+	})
+
+	require.Len(t, got, 1)
+	require.Equal(t, http.StatusOK, got[0].StatusCode)
+	require.Equal(t, map[string]string{"Grpc-Status": "0"}, got[0].Trailers)
+}
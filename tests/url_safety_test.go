@@ -0,0 +1,123 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	errorCodeInvalidURL = "invalid_url"
+	errorCodeUnsafeURL  = "unsafe_url"
+)
+
+func TestCrawlUnsafeURLs(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var hits atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	unsafe := []string{
+		srv.URL + "/a\r\nX-Injected: 1",
+		srv.URL + "/a\nb",
+		srv.URL + "/a\rb",
+		srv.URL + "/a\tb",
+		srv.URL + "/a\x00b",
+		srv.URL + "/a\x7fb",
+		"http://exa%0d%0ample.com/",
+	}
+
+	urls := append([]string{srv.URL + "/ok"}, unsafe...)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   len(urls),
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, len(urls))
+
+	require.Equal(t, urls[0], got[0].URL)
+	require.Empty(t, got[0].Error)
+	require.Empty(t, got[0].ErrorCode)
+	require.Equal(t, http.StatusNoContent, got[0].StatusCode)
+
+	for i := 1; i < len(urls); i++ {
+		require.Equal(t, urls[i], got[i].URL)
+		require.NotEmpty(t, got[i].Error)
+		require.Equal(t, errorCodeUnsafeURL, got[i].ErrorCode, "url %q", urls[i])
+		require.Zero(t, got[i].StatusCode)
+	}
+
+	require.EqualValues(t, 1, hits.Load(), "unsafe urls must not reach upstream")
+}
+
+func TestCrawlInvalidURLErrorCode(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	urls := []string{
+		"http://example.com:abc",
+		"://example.com",
+	}
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   len(urls),
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, len(urls))
+	for i := range urls {
+		require.NotEmpty(t, got[i].Error)
+		require.Equal(t, errorCodeInvalidURL, got[i].ErrorCode)
+	}
+}
+
+func FuzzNormalizeURL(f *testing.F) {
+	seeds := []string{
+		"http://example.com",
+		"http://example.com:80/a/b/../c?b=2&a=1",
+		"https://EXAMPLE.com/a%2Fb",
+		"http://example.com/a\r\nX-Injected: 1",
+		"http://[::1]:8080/",
+		"http://%41",
+		"://example.com",
+	}
+
+	for _, s := range seeds {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		normalized, err := normalizeURL(raw)
+		if err != nil {
+			return
+		}
+
+		require.False(t, strings.ContainsFunc(normalized, func(r rune) bool {
+			return r < 0x20 || r == 0x7f
+		}), "normalized url contains control characters: %q", normalized)
+
+		again, err := normalizeURL(normalized)
+		require.NoError(t, err)
+		require.Equal(t, normalized, again)
+	})
+}
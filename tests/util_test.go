@@ -3,6 +3,7 @@ package crawler
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"net/url"
 	"runtime"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -17,7 +19,10 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-const crawlPath = "/crawl"
+const (
+	crawlPath = "/crawl"
+	jobsPath  = "/jobs"
+)
 
 const (
 	serverUpTTL   = time.Second
@@ -29,10 +34,23 @@ const (
 	contentTypeJson = "application/json"
 )
 
-func startCrawlerServer(ctx context.Context, t *testing.T) (baseURL *url.URL, stopWait func()) {
+type listenAndServer interface {
+	ListenAndServe(ctx context.Context, address string) error
+}
+
+func startCrawlerServer(ctx context.Context, t testing.TB) (baseURL *url.URL, stopWait func()) {
+	t.Helper()
+	return serveCrawler(ctx, t, New())
+}
+
+func startCrawlerServerWithClock(ctx context.Context, t testing.TB, clk clock) (baseURL *url.URL, stopWait func()) {
+	t.Helper()
+	return serveCrawler(ctx, t, newCrawler(clk))
+}
+
+func serveCrawler(ctx context.Context, t testing.TB, c listenAndServer) (baseURL *url.URL, stopWait func()) {
 	t.Helper()
 
-	c := New()
 	port := findFreePort(t)
 	errCh := make(chan error, 1)
 
@@ -59,7 +77,30 @@ func startCrawlerServer(ctx context.Context, t *testing.T) (baseURL *url.URL, st
 	return fullAddr, stopWait
 }
 
-func waitHTTPUp(t *testing.T, baseURL *url.URL, timeout time.Duration) bool {
+// requireStartError проверяет, что ListenAndServe отказывается стартовать. Если проверка конфигурации
+// пропущена и сервер начал слушать, тест падает через serverDownTTL, а не висит до таймаута пакета
+func requireStartError(t testing.TB, c listenAndServer, msgAndArgs ...any) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	port := findFreePort(t)
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- c.ListenAndServe(ctx, port)
+	}()
+
+	select {
+	case err := <-errCh:
+		require.Error(t, err, msgAndArgs...)
+	case <-time.After(serverDownTTL):
+		require.Fail(t, "ListenAndServe started with invalid configuration", msgAndArgs...)
+	}
+}
+
+func waitHTTPUp(t testing.TB, baseURL *url.URL, timeout time.Duration) bool {
 	deadline := time.Now().Add(timeout)
 	client := &http.Client{Timeout: 200 * time.Millisecond}
 	p := constructCrawlPath(t, baseURL)
@@ -78,11 +119,117 @@ func waitHTTPUp(t *testing.T, baseURL *url.URL, timeout time.Duration) bool {
 	return false
 }
 
-func constructCrawlPath(t *testing.T, baseURL *url.URL) *url.URL {
+func constructCrawlPath(t testing.TB, baseURL *url.URL) *url.URL {
 	t.Helper()
 	return baseURL.JoinPath(crawlPath)
 }
 
+func postCrawl(t testing.TB, c *http.Client, baseURL *url.URL, body any) *http.Response {
+	t.Helper()
+
+	reqBody, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	resp, err := c.Post(constructCrawlPath(t, baseURL).String(), contentTypeJson, bytes.NewReader(reqBody))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		resp.Body.Close()
+	})
+
+	return resp
+}
+
+func crawl(t testing.TB, c *http.Client, baseURL *url.URL, body any) []CrawlResponse {
+	t.Helper()
+
+	resp := postCrawl(t, c, baseURL, body)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got []CrawlResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	return got
+}
+
+// crawlResult - итог crawlAsync, который проверяется уже в горутине теста
+type crawlResult struct {
+	status  int
+	results []CrawlResponse
+	err     error
+}
+
+// crawlAsync выполняет /crawl в отдельной горутине и не вызывает require: t.FailNow можно вызывать
+// только из горутины теста
+func crawlAsync(c *http.Client, baseURL *url.URL, body any) <-chan crawlResult {
+	out := make(chan crawlResult, 1)
+
+	go func() {
+		defer close(out)
+
+		reqBody, err := json.Marshal(body)
+		if err != nil {
+			out <- crawlResult{err: err}
+			return
+		}
+
+		resp, err := c.Post(baseURL.JoinPath(crawlPath).String(), contentTypeJson, bytes.NewReader(reqBody))
+		if err != nil {
+			out <- crawlResult{err: err}
+			return
+		}
+
+		defer resp.Body.Close()
+
+		res := crawlResult{status: resp.StatusCode}
+		if resp.StatusCode == http.StatusOK {
+			res.err = json.NewDecoder(resp.Body).Decode(&res.results)
+		}
+
+		out <- res
+	}()
+
+	return out
+}
+
+// awaitCrawl дожидается crawlAsync и проверяет, что обход завершился с 200 OK
+func awaitCrawl(t testing.TB, ch <-chan crawlResult) []CrawlResponse {
+	t.Helper()
+
+	res := <-ch
+	require.NoError(t, res.err)
+	require.Equal(t, http.StatusOK, res.status)
+
+	return res.results
+}
+
+func constructJobsPath(t testing.TB, baseURL *url.URL, elem ...string) *url.URL {
+	t.Helper()
+	return baseURL.JoinPath(append([]string{jobsPath}, elem...)...)
+}
+
+func submitJob(t testing.TB, c *http.Client, baseURL *url.URL, body any) string {
+	t.Helper()
+
+	reqBody, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	resp, err := c.Post(constructJobsPath(t, baseURL).String(), contentTypeJson, bytes.NewReader(reqBody))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var job struct {
+		ID string `json:"id"`
+	}
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+	require.NotEmpty(t, job.ID)
+
+	return job.ID
+}
+
 func client() *http.Client {
 	return &http.Client{
 		Transport: &http.Transport{
@@ -102,7 +249,7 @@ func client() *http.Client {
 	}
 }
 
-func makeURLs(t *testing.T, baseURL string, n int) []string {
+func makeURLs(t testing.TB, baseURL string, n int) []string {
 	t.Helper()
 
 	urls := make([]string, n)
@@ -112,7 +259,7 @@ func makeURLs(t *testing.T, baseURL string, n int) []string {
 	return urls
 }
 
-func findFreePort(t *testing.T) string {
+func findFreePort(t testing.TB) string {
 	t.Helper()
 
 	for {
@@ -148,3 +295,80 @@ func newConnCountingServer(t *testing.T) (srv *httptest.Server, conns *atomic.In
 	srv.Start()
 	return srv, conns
 }
+
+// fakeClock реализует clock краулера и двигается только через Advance
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+
+		w.ch <- c.now
+	}
+
+	c.waiters = pending
+}
+
+// Pending возвращает оставшееся время ещё не сработавших After
+func (c *fakeClock) Pending() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make([]time.Duration, 0, len(c.waiters))
+	for _, w := range c.waiters {
+		out = append(out, w.deadline.Sub(c.now))
+	}
+
+	return out
+}
+
+// Waiters возвращает количество ещё не сработавших After
+func (c *fakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.waiters)
+}
@@ -0,0 +1,128 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheHonorsVary(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var hits atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Vary", "accept-language")
+
+		if r.Header.Get("Accept-Language") == "fr" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	crawlWith := func(headers map[string]string) int {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{srv.URL},
+			Workers:   1,
+			TimeoutMS: 2000,
+			Headers:   headers,
+		})
+
+		require.Len(t, got, 1)
+		require.Empty(t, got[0].Error)
+
+		return got[0].StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, crawlWith(map[string]string{"Accept-Language": "en"}))
+	require.EqualValues(t, 1, hits.Load())
+
+	require.Equal(t, http.StatusOK, crawlWith(map[string]string{"Accept-Language": "en", "X-Other": "1"}))
+	require.EqualValues(t, 1, hits.Load())
+
+	require.Equal(t, http.StatusNotFound, crawlWith(map[string]string{"Accept-Language": "fr"}))
+	require.EqualValues(t, 2, hits.Load())
+
+	require.Equal(t, http.StatusOK, crawlWith(nil))
+	require.EqualValues(t, 3, hits.Load())
+
+	// все три варианта живут в кэше одновременно
+	require.Equal(t, http.StatusOK, crawlWith(map[string]string{"accept-language": "en"}))
+	require.Equal(t, http.StatusNotFound, crawlWith(map[string]string{"Accept-Language": "fr"}))
+	require.Equal(t, http.StatusOK, crawlWith(nil))
+	require.EqualValues(t, 3, hits.Load())
+}
+
+func TestCacheVaryStar(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var hits atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Vary", "*")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	const k = 3
+	for range k {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{srv.URL},
+			Workers:   1,
+			TimeoutMS: 2000,
+		})
+
+		require.Len(t, got, 1)
+		require.Equal(t, http.StatusOK, got[0].StatusCode)
+	}
+
+	require.EqualValues(t, k, hits.Load())
+}
+
+func TestCacheWithoutVaryIgnoresHeaders(t *testing.T) {
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	var hits atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	for _, lang := range []string{"en", "fr", "de"} {
+		got := crawl(t, c, baseUrl, CrawlRequest{
+			URLs:      []string{srv.URL},
+			Workers:   1,
+			TimeoutMS: 2000,
+			Headers:   map[string]string{"Accept-Language": lang},
+		})
+
+		require.Len(t, got, 1)
+		require.Equal(t, http.StatusOK, got[0].StatusCode)
+	}
+
+	require.EqualValues(t, 1, hits.Load())
+}
@@ -0,0 +1,187 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const warcDirEnv = "CRAWLER_WARC_DIR"
+
+type warcRecord struct {
+	Header textproto.MIMEHeader
+	Block  []byte
+}
+
+func readWARC(t *testing.T, path string) []warcRecord {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	// gzip.Reader по умолчанию читает все members подряд
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	defer gz.Close()
+
+	r := bufio.NewReader(gz)
+
+	var records []warcRecord
+	for {
+		version, err := r.ReadString('\n')
+		if err == io.EOF && version == "" {
+			break
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, "WARC/1.1\r\n", version)
+
+		header, err := textproto.NewReader(r).ReadMIMEHeader()
+		require.NoError(t, err)
+
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		require.NoError(t, err)
+
+		block := make([]byte, length)
+		_, err = io.ReadFull(r, block)
+		require.NoError(t, err)
+
+		trailer := make([]byte, 4)
+		_, err = io.ReadFull(r, trailer)
+		require.NoError(t, err)
+		require.Equal(t, "\r\n\r\n", string(trailer))
+
+		records = append(records, warcRecord{Header: header, Block: block})
+	}
+
+	return records
+}
+
+func TestJobWARC(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(warcDirEnv, dir)
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("archived " + r.URL.Path))
+	}))
+
+	t.Cleanup(srv.Close)
+
+	closed := closedServerURL(t)
+	urls := []string{srv.URL + "/a", srv.URL + "/b", closed.String() + "/dead"}
+
+	id := submitJob(t, c, baseUrl, CrawlRequest{
+		URLs:      urls,
+		Workers:   2,
+		TimeoutMS: 3000,
+		WARC:      true,
+	})
+
+	waitJob(t, c, baseUrl, id)
+
+	path := filepath.Join(dir, id+".warc.gz")
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 20*time.Millisecond)
+
+	records := readWARC(t, path)
+	require.Len(t, records, 1+2*2)
+	require.Equal(t, "warcinfo", records[0].Header.Get("WARC-Type"))
+
+	ids := make(map[string]bool)
+	responses := make(map[string]warcRecord)
+	requests := make(map[string]warcRecord)
+
+	for _, rec := range records {
+		recordID := rec.Header.Get("WARC-Record-ID")
+		require.True(t, strings.HasPrefix(recordID, "<urn:uuid:"), recordID)
+		require.False(t, ids[recordID], "duplicate record id %s", recordID)
+		ids[recordID] = true
+
+		require.NotEmpty(t, rec.Header.Get("WARC-Date"))
+
+		switch rec.Header.Get("WARC-Type") {
+		case "request":
+			require.Equal(t, "application/http;msgtype=request", rec.Header.Get("Content-Type"))
+			requests[rec.Header.Get("WARC-Target-URI")] = rec
+		case "response":
+			require.Equal(t, "application/http;msgtype=response", rec.Header.Get("Content-Type"))
+			responses[rec.Header.Get("WARC-Target-URI")] = rec
+		}
+	}
+
+	for _, u := range urls[:2] {
+		resp, ok := responses[u]
+		require.True(t, ok, u)
+		require.True(t, bytes.HasPrefix(resp.Block, []byte("HTTP/1.1 200")), string(resp.Block))
+
+		urlPath := u[strings.LastIndex(u, "/"):]
+		require.True(t, bytes.HasSuffix(resp.Block, []byte("archived "+urlPath)), string(resp.Block))
+
+		req, ok := requests[u]
+		require.True(t, ok, u)
+		require.True(t, bytes.HasPrefix(req.Block, []byte("GET "+urlPath+" HTTP/1.1\r\n")), string(req.Block))
+		require.Equal(t, resp.Header.Get("WARC-Record-ID"), req.Header.Get("WARC-Concurrent-To"))
+	}
+
+	_, err := os.Stat(path + ".open")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestWARCWithoutDir(t *testing.T) {
+	t.Setenv(warcDirEnv, "")
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	resp := postWithKey(t, c, constructJobsPath(t, baseUrl), "", CrawlRequest{
+		URLs:      []string{"http://127.0.0.1/"},
+		Workers:   1,
+		TimeoutMS: 1000,
+		WARC:      true,
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCrawlWARCRejected(t *testing.T) {
+	t.Setenv(warcDirEnv, t.TempDir())
+
+	baseUrl, stopWait := startCrawlerServer(t.Context(), t)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	resp := postCrawl(t, c, baseUrl, CrawlRequest{
+		URLs:      []string{"http://127.0.0.1/"},
+		Workers:   1,
+		TimeoutMS: 1000,
+		WARC:      true,
+	})
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
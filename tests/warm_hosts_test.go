@@ -0,0 +1,261 @@
+//go:build model_test
+
+package crawler
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setWarmHosts(t *testing.T, hosts []warmHost) {
+	t.Helper()
+
+	prev := warmHosts
+	warmHosts = hosts
+
+	t.Cleanup(func() {
+		warmHosts = prev
+	})
+}
+
+// newWarmServer считает новые соединения и лёгкие запросы HEAD /ping
+func newWarmServer(t *testing.T) (srv *httptest.Server, conns, pings *atomic.Int64) {
+	t.Helper()
+
+	conns = &atomic.Int64{}
+	pings = &atomic.Int64{}
+
+	srv = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead && r.URL.Path == "/ping" {
+			pings.Add(1)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	return srv, conns, pings
+}
+
+func TestWarmHosts(t *testing.T) {
+	const (
+		n        = 2
+		interval = 10 * time.Second
+	)
+
+	srv, conns, pings := newWarmServer(t)
+	setWarmHosts(t, []warmHost{{origin: srv.URL, conns: n, interval: interval, path: "/ping"}})
+
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	require.Eventually(t, func() bool {
+		return conns.Load() == n && pings.Load() == n
+	}, time.Second, 10*time.Millisecond)
+
+	// тик пингует простаивающие соединения, новых не открывается
+	clk.Advance(interval)
+
+	require.Eventually(t, func() bool {
+		return pings.Load() == 2*n
+	}, time.Second, 10*time.Millisecond)
+
+	require.EqualValues(t, n, conns.Load())
+
+	// обход берёт прогретые соединения из общего пула
+	got := crawl(t, client(), baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL, n),
+		Workers:   n,
+		TimeoutMS: 2000,
+	})
+
+	require.Len(t, got, n)
+	for _, r := range got {
+		require.Equal(t, http.StatusNoContent, r.StatusCode)
+	}
+
+	require.EqualValues(t, n, conns.Load())
+
+	// закрытые сервером соединения заменяются на ближайшем тике
+	srv.CloseClientConnections()
+	clk.Advance(interval)
+
+	require.Eventually(t, func() bool {
+		return conns.Load() == 2*n
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWarmHostsSkipQuarantined(t *testing.T) {
+	const interval = 10 * time.Second
+
+	var pings atomic.Int64
+
+	// лёгкие запросы проходят, а обычные обрываются и дают fetch_failed
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead && r.URL.Path == "/ping" {
+			pings.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	setWarmHosts(t, []warmHost{{origin: srv.URL, conns: 1, interval: interval, path: "/ping"}})
+
+	clk := newFakeClock()
+	baseUrl, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	c := client()
+
+	require.Eventually(t, func() bool {
+		return pings.Load() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	got := crawl(t, c, baseUrl, CrawlRequest{
+		URLs:      makeURLs(t, srv.URL, quarantineErrorThreshold),
+		Workers:   1,
+		TimeoutMS: 5000,
+	})
+
+	require.Len(t, got, quarantineErrorThreshold)
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	require.NotEmpty(t, getQuarantine(t, c, baseUrl))
+
+	clk.Advance(interval)
+	time.Sleep(100 * time.Millisecond)
+
+	require.EqualValues(t, 1, pings.Load(), "quarantined host must not be pinged")
+
+	require.Equal(t, http.StatusNoContent, liftQuarantine(t, c, baseUrl, u.Host))
+	clk.Advance(interval)
+
+	require.Eventually(t, func() bool {
+		return pings.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	m := scrapeMetrics(t, c, baseUrl)
+	require.InDelta(t, 2, m["crawler_warm_pings_total"], 0)
+}
+
+func TestWarmHostsMaxPerHost(t *testing.T) {
+	const interval = 10 * time.Second
+
+	t.Setenv("CRAWLER_MAX_PER_HOST", "1")
+
+	srv, conns, pings := newWarmServer(t)
+	setWarmHosts(t, []warmHost{{origin: srv.URL, conns: 3, interval: interval, path: "/ping"}})
+
+	clk := newFakeClock()
+	_, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	require.Eventually(t, func() bool {
+		return conns.Load() == 1 && pings.Load() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.Never(t, func() bool {
+		return conns.Load() > 1
+	}, 200*time.Millisecond, 10*time.Millisecond)
+
+	clk.Advance(interval)
+
+	require.Eventually(t, func() bool {
+		return pings.Load() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	require.EqualValues(t, 1, conns.Load(), "warm connections must not exceed CRAWLER_MAX_PER_HOST")
+}
+
+func TestWarmHostsCrawlDelay(t *testing.T) {
+	const (
+		interval   = 10 * time.Second
+		crawlDelay = 30 * time.Second
+		steps      = 9
+	)
+
+	var robots, pings atomic.Int64
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/robots.txt":
+			robots.Add(1)
+			w.Write([]byte("User-agent: *\nCrawl-delay: 30\n"))
+		case r.Method == http.MethodHead && r.URL.Path == "/ping":
+			pings.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	setWarmHosts(t, []warmHost{{origin: srv.URL, conns: 2, interval: interval, path: "/ping"}})
+
+	clk := newFakeClock()
+	_, stopWait := startCrawlerServerWithClock(t.Context(), t, clk)
+	t.Cleanup(stopWait)
+
+	require.Eventually(t, func() bool {
+		return robots.Load() == 1 && pings.Load() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// второе соединение на старте тоже ждёт Crawl-delay
+	require.Never(t, func() bool {
+		return pings.Load() > 1
+	}, 200*time.Millisecond, 10*time.Millisecond)
+
+	// без Crawl-delay за это время было бы 2 пинга на каждый interval
+	for step := 1; step <= steps; step++ {
+		clk.Advance(interval)
+
+		allowed := int64(time.Duration(step)*interval/crawlDelay) + 1
+		require.Never(t, func() bool {
+			return pings.Load() > allowed
+		}, 100*time.Millisecond, 10*time.Millisecond, "after %v", time.Duration(step)*interval)
+	}
+
+	require.Eventually(t, func() bool {
+		return pings.Load() >= 3
+	}, time.Second, 10*time.Millisecond, "warm pings must continue at the Crawl-delay pace")
+
+	require.EqualValues(t, 1, robots.Load())
+}
+
+func TestWarmHostsInvalid(t *testing.T) {
+	for _, h := range []warmHost{
+		{origin: "ftp://example.com:21", conns: 1, interval: time.Minute},
+		{origin: "http://example.com:80", conns: 0, interval: time.Minute},
+		{origin: "http://example.com:80", conns: 17, interval: time.Minute},
+		{origin: "http://example.com:80", conns: 1, interval: warmMinInterval / 2},
+	} {
+		setWarmHosts(t, []warmHost{h})
+
+		requireStartError(t, New(), "%+v", h)
+	}
+}